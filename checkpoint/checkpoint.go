@@ -0,0 +1,231 @@
+package checkpoint
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chn0318/logstore/mapservice"
+)
+
+// File layout:
+//
+//	magic(4) | version(4) | bodyLen(8) | body(bodyLen) | crc32c(body)(4)
+//
+// body is the JSON encoding of a mapservice.Snapshot. Version 1 stored a
+// single KeyMeta per key instead of a version chain; Load still reads it.
+const (
+	magic   uint32 = 0x4c53434b // "LSCK"
+	version uint32 = 2
+
+	headerSize  = 16
+	trailerSize = 4
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorrupt is returned by Load when the checkpoint file fails validation.
+var ErrCorrupt = errors.New("checkpoint: corrupt file")
+
+// Save atomically writes snap to path: the data goes to a temporary file in
+// the same directory, is fsynced, and is then renamed over path.
+func Save(path string, snap mapservice.Snapshot) error {
+	body, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, headerSize+len(body)+trailerSize)
+	binary.BigEndian.PutUint32(buf[0:4], magic)
+	binary.BigEndian.PutUint32(buf[4:8], version)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(len(body)))
+	copy(buf[headerSize:], body)
+	binary.BigEndian.PutUint32(buf[headerSize+len(body):], crc32.Checksum(body, crcTable))
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Load reads the checkpoint at path. found is false if no checkpoint exists.
+func Load(path string) (snap mapservice.Snapshot, found bool, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return mapservice.Snapshot{}, false, nil
+	}
+	if err != nil {
+		return mapservice.Snapshot{}, false, err
+	}
+	defer f.Close()
+
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: short header: %v", ErrCorrupt, err)
+	}
+	if m := binary.BigEndian.Uint32(hdr[0:4]); m != magic {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: bad magic %#x", ErrCorrupt, m)
+	}
+	v := binary.BigEndian.Uint32(hdr[4:8])
+	if v != version && v != 1 {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: unsupported version %d", ErrCorrupt, v)
+	}
+	n := binary.BigEndian.Uint64(hdr[8:16])
+	st, err := f.Stat()
+	if err != nil {
+		return mapservice.Snapshot{}, true, err
+	}
+	if uint64(st.Size()) != headerSize+n+trailerSize {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: size mismatch", ErrCorrupt)
+	}
+
+	rest := make([]byte, n+trailerSize)
+	if _, err := io.ReadFull(f, rest); err != nil {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: short body: %v", ErrCorrupt, err)
+	}
+	body := rest[:n]
+	if crc := binary.BigEndian.Uint32(rest[n:]); crc != crc32.Checksum(body, crcTable) {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	if v == 1 {
+		snap, err = decodeV1(body)
+	} else {
+		err = json.Unmarshal(body, &snap)
+	}
+	if err != nil {
+		return mapservice.Snapshot{}, true, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return snap, true, nil
+}
+
+func decodeV1(body []byte) (mapservice.Snapshot, error) {
+	var old struct {
+		Keys         map[string]mapservice.KeyMeta
+		MaxCommitGSN uint64
+	}
+	if err := json.Unmarshal(body, &old); err != nil {
+		return mapservice.Snapshot{}, err
+	}
+	snap := mapservice.Snapshot{
+		Keys:         make(map[string][]mapservice.KeyMeta, len(old.Keys)),
+		MaxCommitGSN: old.MaxCommitGSN,
+	}
+	for k, meta := range old.Keys {
+		snap.Keys[k] = []mapservice.KeyMeta{meta}
+	}
+	return snap, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Checkpointer periodically saves a MapService snapshot to a file.
+type Checkpointer struct {
+	ms       *mapservice.MapService
+	path     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	lastGSN uint64
+	saved   bool
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+func NewCheckpointer(ms *mapservice.MapService, path string, interval time.Duration) *Checkpointer {
+	return &Checkpointer{
+		ms:       ms,
+		path:     path,
+		interval: interval,
+		stopC:    make(chan struct{}),
+		doneC:    make(chan struct{}),
+	}
+}
+
+// Start runs the periodic checkpoint loop in the background.
+func (c *Checkpointer) Start() {
+	go c.run()
+}
+
+func (c *Checkpointer) run() {
+	defer close(c.doneC)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Checkpoint(); err != nil {
+				log.Printf("checkpoint: %v", err)
+			}
+		case <-c.stopC:
+			return
+		}
+	}
+}
+
+// Checkpoint writes a snapshot now. It is a no-op if nothing has been
+// committed since the last successful checkpoint.
+func (c *Checkpointer) Checkpoint() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap := c.ms.Snapshot()
+	if c.saved && snap.MaxCommitGSN == c.lastGSN {
+		return nil
+	}
+	if err := Save(c.path, snap); err != nil {
+		return err
+	}
+	c.lastGSN = snap.MaxCommitGSN
+	c.saved = true
+	return nil
+}
+
+// LastGSN returns the MaxCommitGSN covered by the last successful checkpoint.
+func (c *Checkpointer) LastGSN() (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastGSN, c.saved
+}
+
+// Stop ends the background loop and writes a final checkpoint.
+func (c *Checkpointer) Stop() error {
+	close(c.stopC)
+	<-c.doneC
+	return c.Checkpoint()
+}
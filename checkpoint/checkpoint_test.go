@@ -0,0 +1,127 @@
+package checkpoint
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// writeFile writes a checkpoint file with the given version and body and a
+// valid checksum.
+func writeFile(t *testing.T, path string, v uint32, body []byte) {
+	t.Helper()
+	buf := make([]byte, headerSize+len(body)+trailerSize)
+	binary.BigEndian.PutUint32(buf[0:4], magic)
+	binary.BigEndian.PutUint32(buf[4:8], v)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(len(body)))
+	copy(buf[headerSize:], body)
+	binary.BigEndian.PutUint32(buf[headerSize+len(body):], crc32.Checksum(body, crcTable))
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ckpt")
+	if _, found, err := Load(path); found || err != nil {
+		t.Fatalf("Load of a missing file = %v, %v", found, err)
+	}
+
+	snap := mapservice.Snapshot{
+		Keys: map[string][]mapservice.KeyMeta{
+			"a": {
+				{Ref: sharedlog.RecordRef{GSN: 1}, CommitGSN: 2},
+				{Ref: sharedlog.RecordRef{GSN: 5, ShardID: 1}, CommitGSN: 6},
+			},
+			"b": {{CommitGSN: 7, Deleted: true}},
+		},
+		MaxCommitGSN: 7,
+		Horizon:      3,
+		Sessions:     map[string]mapservice.ClientSession{"c": {Seq: 4, CommitGSN: 6}},
+	}
+	if err := Save(path, snap); err != nil {
+		t.Fatal(err)
+	}
+	// saving again replaces the file
+	if err := Save(path, snap); err != nil {
+		t.Fatal(err)
+	}
+	got, found, err := Load(path)
+	if err != nil || !found {
+		t.Fatalf("Load = %v, %v", found, err)
+	}
+	if !reflect.DeepEqual(got, snap) {
+		t.Fatalf("Load = %+v, want %+v", got, snap)
+	}
+	ents, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ents) != 1 {
+		t.Fatalf("%d files next to the checkpoint, want only it", len(ents))
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		damage func(buf []byte) []byte
+	}{
+		{"checksum", func(buf []byte) []byte { buf[headerSize] ^= 0xff; return buf }},
+		{"truncated", func(buf []byte) []byte { return buf[:len(buf)-1] }},
+		{"magic", func(buf []byte) []byte { buf[0] ^= 0xff; return buf }},
+		{"version", func(buf []byte) []byte { binary.BigEndian.PutUint32(buf[4:8], version+1); return buf }},
+		{"empty", func(buf []byte) []byte { return nil }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ckpt")
+			snap := mapservice.Snapshot{
+				Keys:         map[string][]mapservice.KeyMeta{"k": {{Ref: sharedlog.RecordRef{GSN: 1}, CommitGSN: 2}}},
+				MaxCommitGSN: 2,
+			}
+			if err := Save(path, snap); err != nil {
+				t.Fatal(err)
+			}
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tc.damage(buf), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, found, err := Load(path); !found || !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("Load = %v, %v, want found and ErrCorrupt", found, err)
+			}
+		})
+	}
+}
+
+func TestLoadV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ckpt")
+	// version 1 kept only the latest KeyMeta of each key
+	writeFile(t, path, 1, []byte(`{"Keys":{"k":{"Ref":{"GSN":3,"ShardID":0},"CommitGSN":4}},"MaxCommitGSN":4}`))
+	got, found, err := Load(path)
+	if err != nil || !found {
+		t.Fatalf("Load = %v, %v", found, err)
+	}
+	want := mapservice.Snapshot{
+		Keys:         map[string][]mapservice.KeyMeta{"k": {{Ref: sharedlog.RecordRef{GSN: 3}, CommitGSN: 4}}},
+		MaxCommitGSN: 4,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %+v, want %+v", got, want)
+	}
+
+	// a v1 body that is not valid JSON is corrupt even with a good checksum
+	writeFile(t, path, 1, []byte(`{"Keys":`))
+	if _, _, err := Load(path); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Load of a bad v1 body: err = %v, want ErrCorrupt", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -26,21 +27,37 @@ func main() {
 	defer cancel()
 
 	log.Println("=== MultiPut ===")
-	_, err = client.MultiPut(ctx, &storagepb.MultiPutRequest{
+	putReq := &storagepb.MultiPutRequest{
 		Kvs: []*storagepb.KV{
 			{Key: "k1", Value: []byte("v1")},
 			{Key: "k2", Value: []byte("v2")},
 			{Key: "k3", Value: []byte("v3")},
 		},
-	})
+		ClientId: fmt.Sprintf("client-%d", time.Now().UnixNano()),
+		Seq:      1,
+	}
+	putResp, err := client.MultiPut(ctx, putReq)
 	if err != nil {
 		log.Fatalf("MultiPut error: %v", err)
 	}
-	log.Println("MultiPut OK")
+	log.Printf("MultiPut OK, commit_gsn=%d", putResp.CommitGsn)
+	for k, ref := range putResp.Refs {
+		log.Printf("key=%s, data gsn=%d", k, ref.Gsn)
+	}
+
+	// 重发同一个请求（比如超时之后）：服务端返回原来的结果，不会再提交一次
+	retryResp, err := client.MultiPut(ctx, putReq)
+	if err != nil {
+		log.Fatalf("MultiPut retry error: %v", err)
+	}
+	log.Printf("MultiPut retry OK, commit_gsn=%d (deduplicated: %v)",
+		retryResp.CommitGsn, retryResp.CommitGsn == putResp.CommitGsn)
 
 	log.Println("=== MultiGet ===")
+	// min_commit_gsn 保证读到刚才的写，即使这是另一台 storage server
 	getResp, err := client.MultiGet(ctx, &storagepb.MultiGetRequest{
-		Keys: []string{"k1", "k2", "k3", "k-not-exist"},
+		Keys:         []string{"k1", "k2", "k3", "k-not-exist"},
+		MinCommitGsn: putResp.CommitGsn,
 	})
 	if err != nil {
 		log.Fatalf("MultiGet error: %v", err)
@@ -49,4 +66,35 @@ func main() {
 	for k, v := range getResp.Values {
 		log.Printf("key=%s, value=%s\n", k, string(v))
 	}
+
+	log.Println("=== Txn ===")
+	reads := make([]*storagepb.ReadItem, 0, len(getResp.CommitGsns))
+	for k, gsn := range getResp.CommitGsns {
+		reads = append(reads, &storagepb.ReadItem{Key: k, CommitGsn: gsn})
+	}
+	txnResp, err := client.Txn(ctx, &storagepb.TxnRequest{
+		Reads:  reads,
+		Writes: []*storagepb.KV{{Key: "k1", Value: []byte("v1-txn")}},
+	})
+	if err != nil {
+		log.Fatalf("Txn error: %v", err)
+	}
+	log.Printf("Txn OK, commit_gsn=%d", txnResp.CommitGsn)
+
+	log.Println("=== Watch ===")
+	// 从 MultiPut 之后开始，应当先收到 Txn 对 k1 的修改
+	stream, err := client.Watch(ctx, &storagepb.WatchRequest{
+		Keys:     []string{"k1"},
+		StartGsn: putResp.CommitGsn,
+	})
+	if err != nil {
+		log.Fatalf("Watch error: %v", err)
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		log.Fatalf("Watch error: %v", err)
+	}
+	for _, kv := range ev.Kvs {
+		log.Printf("commit_gsn=%d key=%s, value=%s, delete=%v", ev.CommitGsn, kv.Key, string(kv.Value), kv.Delete)
+	}
 }
@@ -0,0 +1,77 @@
+// Command corfu runs one component of the CORFU log backend: either the
+// sequencer or a storage unit.
+//
+//	corfu -role unit -listen :7001
+//	corfu -role sequencer -listen :7000 -units localhost:7001,localhost:7002
+//
+// A sequencer given -units starts after the highest position on those units,
+// so it can be restarted without handing out GSNs twice.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/chn0318/logstore/sharedlog/corfu"
+)
+
+func main() {
+	role := flag.String("role", "", "component to run: sequencer or unit")
+	listen := flag.String("listen", ":7000", "listen address")
+	units := flag.String("units", "", "comma-separated storage unit addresses to recover the sequencer from")
+	flag.Parse()
+
+	grpcServer := grpc.NewServer()
+	switch *role {
+	case "sequencer":
+		seq, err := newSequencer(*units)
+		if err != nil {
+			log.Fatalf("sequencer recovery error: %v", err)
+		}
+		corfu.RegisterSequencer(grpcServer, seq)
+	case "unit":
+		corfu.RegisterStorageUnit(grpcServer, corfu.NewStorageUnit())
+	default:
+		log.Fatalf("unknown -role %q, want sequencer or unit", *role)
+	}
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("listen error: %v", err)
+	}
+	log.Printf("corfu %s listening on %s", *role, *listen)
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("serve error: %v", err)
+	}
+}
+
+func newSequencer(addrs string) (*corfu.LocalSequencer, error) {
+	if addrs == "" {
+		return corfu.NewSequencer(1), nil
+	}
+	var units []corfu.StorageUnit
+	for _, addr := range strings.Split(addrs, ",") {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		units = append(units, corfu.NewRemoteUnit(conn))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	seq, err := corfu.RecoverSequencer(ctx, units)
+	if err != nil {
+		return nil, err
+	}
+	tail, _ := seq.Tail(ctx)
+	log.Printf("corfu sequencer recovered, tail=%d", tail)
+	return seq, nil
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	storagepb "github.com/chn0318/logstore/proto/storagepb"
 )
@@ -21,6 +23,8 @@ func main() {
 	concurrency := flag.Int("concurrency", 32, "number of concurrent workers")
 	keysPerReq := flag.Int("keys-per-req", 10, "number of keys per MultiPut request")
 	valueSize := flag.Int("value-bytes", 4*1024, "value size in bytes")
+	reqTimeout := flag.Duration("request-timeout", 0, "per-attempt MultiPut timeout (0 = none)")
+	retries := flag.Int("retries", 3, "times a MultiPut that timed out or hit an unavailable server is resent")
 
 	flag.Parse()
 
@@ -61,14 +65,16 @@ func main() {
 		startTime = time.Now()
 	)
 
-	// 4. 启动 worker
+	// 4. 启动 worker；每个 worker 用自己的 client id，重发的请求不会被提交两次
+	runID := time.Now().UnixNano()
 	for w := 0; w < *concurrency; w++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
 
+			clientID := fmt.Sprintf("perf-%x-%d", runID, workerID)
+			var seq uint64
 			for j := range jobs {
-				ctx := context.Background()
 				kvs := make([]*storagepb.KV, 0, *keysPerReq)
 				for i := 0; i < *keysPerReq; i++ {
 					kvs := make([]*storagepb.KV, 0, *keysPerReq)
@@ -85,9 +91,12 @@ func main() {
 					}
 				}
 
-				_, err := client.MultiPut(ctx, &storagepb.MultiPutRequest{
-					Kvs: kvs,
-				})
+				seq++
+				err := multiPut(client, &storagepb.MultiPutRequest{
+					Kvs:      kvs,
+					ClientId: clientID,
+					Seq:      seq,
+				}, *reqTimeout, *retries)
 				if err != nil {
 					fmt.Printf("err: %v\n", err)
 					mu.Lock()
@@ -122,3 +131,23 @@ func main() {
 	log.Printf("Throughput:          %.2f req/s", qps)
 	log.Printf("Data throughput:     %.2f MB/s", mbps)
 }
+
+// multiPut sends req, resending it up to retries times while the outcome is
+// unknown. The server deduplicates by client id and seq.
+func multiPut(client storagepb.StorageClient, req *storagepb.MultiPutRequest, timeout time.Duration, retries int) error {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		_, err := client.MultiPut(ctx, req)
+		cancel()
+		switch status.Code(err) {
+		case codes.DeadlineExceeded, codes.Unavailable:
+			if attempt < retries {
+				continue
+			}
+		}
+		return err
+	}
+}
@@ -1,46 +1,281 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/chn0318/logstore/proto/logpb"
 	storagepb "github.com/chn0318/logstore/proto/storagepb"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/chn0318/logstore/checkpoint"
+	"github.com/chn0318/logstore/gc"
 	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/recovery"
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/cachelog"
+	_ "github.com/chn0318/logstore/sharedlog/corfu"
+	_ "github.com/chn0318/logstore/sharedlog/filelog"
+	_ "github.com/chn0318/logstore/sharedlog/memorylog"
+	"github.com/chn0318/logstore/sharedlog/raftlog"
+	"github.com/chn0318/logstore/sharedlog/remotelog"
+	"github.com/chn0318/logstore/sharedlog/retrylog"
 	"github.com/chn0318/logstore/sharedlog/scalog"
 	"github.com/chn0318/logstore/storageserver"
 )
 
 func main() {
-	viper.SetConfigFile("/home/chn/.scalog.yaml")
-	if err := viper.ReadInConfig(); err == nil {
-		log.Printf("Using config file: %v", viper.ConfigFileUsed())
+	pflag.String("config", "", "config file (default $HOME/.scalog.yaml)")
+	pflag.String("listen", ":50051", "gRPC listen address")
+	pflag.String("log-backend", "scalog", "shared log backend: "+strings.Join(sharedlog.Backends(), ", "))
+	pflag.Bool("serve-log", false, "also serve the shared log over gRPC, for servers using the remote backend")
+	pflag.Duration("shutdown-timeout", 10*time.Second, "how long to wait for in-flight requests on SIGTERM")
+	pflag.Parse()
+	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
+		log.Fatalf("flags: %v", err)
 	}
+	// 每个配置项也可以用环境变量设置，例如 LOGSTORE_LOG_BACKEND=memory
+	viper.SetEnvPrefix("logstore")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
 
-	logImpl, err := scalog.NewScalogSystem()
+	viper.SetDefault("checkpoint-path", "logstore.ckpt")
+	viper.SetDefault("checkpoint-interval", "30s")
+	viper.SetDefault("gc-interval", "1m")
+	viper.SetDefault("gc-snapshot-retention", 100000)
+	viper.SetDefault("read-cache-bytes", 64<<20)
+	viper.SetDefault("raft-follow-interval", "100ms")
+	viper.SetDefault("stats-interval", "1m")
+	viper.SetDefault("log-retry-attempts", 5)
+	if cfg := viper.GetString("config"); cfg != "" {
+		viper.SetConfigFile(cfg)
+		if err := viper.ReadInConfig(); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+	} else {
+		viper.SetConfigName(".scalog")
+		viper.SetConfigType("yaml")
+		viper.AddConfigPath("$HOME")
+		if err := viper.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				log.Fatalf("config: %v", err)
+			}
+		}
+	}
+	if f := viper.ConfigFileUsed(); f != "" {
+		log.Printf("Using config file: %v", f)
+	}
+
+	ms := mapservice.NewMapService()
+	// 每个副本只裁掉本地 gc 算出的不再需要的记录；collector 启动前什么都不裁
+	var collectorRef atomic.Pointer[gc.Collector]
+	retain := func() uint64 {
+		if c := collectorRef.Load(); c != nil {
+			return c.LowGSN()
+		}
+		return 0
+	}
+	backend := viper.GetString("log-backend")
+	rawLog, err := sharedlog.OpenBackend(backend, viper.GetViper(), sharedlog.Hooks{
+		// 成为 leader 之后先把别的 leader 写的 commit 追进 map，再开始接受写入
+		OnLeader: func(ctx context.Context, l sharedlog.SharedLog) error {
+			_, err := recovery.CatchUp(ctx, l, ms)
+			return err
+		},
+		Retain: retain,
+	})
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	ms := mapservice.NewMapService()
+	logImpl := rawLog
+	// 重试层要直接包在后端外面，才能按 token 扫描日志找到已经写进去的记录
+	if n := viper.GetInt("log-retry-attempts"); n > 1 {
+		opts := retrylog.DefaultOptions()
+		opts.MaxAttempts = n
+		logImpl = retrylog.NewRetryLog(logImpl, opts)
+	}
+	var cache *cachelog.CachedLog
+	if n := viper.GetInt64("read-cache-bytes"); n > 0 {
+		cache = cachelog.NewCachedLog(logImpl, n)
+		logImpl = cache
+	}
+
+	// 在打开 gRPC 监听之前从 checkpoint + 日志恢复 map-service，否则重启后之前写入的 key 都不可见
+	ckptPath := viper.GetString("checkpoint-path")
+	var checkpointer *checkpoint.Checkpointer
+	if backend == "memory" {
+		// 内存日志每次启动都是空的：旧 checkpoint 引用的记录已经不存在，也不写新的，免得覆盖别的后端的 checkpoint
+		log.Printf("memory backend: checkpoints disabled, not using %s", ckptPath)
+	} else {
+		if _, err := recovery.FromCheckpoint(context.Background(), logImpl, ms, ckptPath); err != nil {
+			log.Fatalf("recovery error: %v", err)
+		}
+		ckptInterval, err := time.ParseDuration(viper.GetString("checkpoint-interval"))
+		if err != nil {
+			log.Fatalf("bad checkpoint-interval: %v", err)
+		}
+		checkpointer = checkpoint.NewCheckpointer(ms, ckptPath, ckptInterval)
+		checkpointer.Start()
+	}
 
-	storageSrv := storageserver.NewStorageServer(logImpl, ms)
+	// raft 副本只有 leader 接受写入，其他副本从日志里追 commit 来保持 map 最新；
+	// 其他后端只在有结果未知的 commit 时追
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	follow := func() bool { return false }
+	if rl, ok := rawLog.(*raftlog.RaftLog); ok {
+		follow = func() bool { return !rl.IsLeader() }
+	}
+	go recovery.Follow(bgCtx, logImpl, ms, viper.GetDuration("raft-follow-interval"), follow)
+
+	gcInterval, err := time.ParseDuration(viper.GetString("gc-interval"))
+	if err != nil {
+		log.Fatalf("bad gc-interval: %v", err)
+	}
+	collector := gc.NewCollector(logImpl, ms, checkpointer, gc.Options{
+		Interval:          gcInterval,
+		SnapshotRetention: uint64(viper.GetInt64("gc-snapshot-retention")),
+		// 内存日志重启后就没了，不用留给恢复：没有 checkpoint 也照样裁
+		NoRecovery: backend == "memory",
+	})
+	collectorRef.Store(collector)
+	collector.Start()
+
+	if interval := viper.GetDuration("stats-interval"); interval > 0 {
+		go logStats(bgCtx, interval, cache, rawLog)
+	}
+
+	srvOpts := storageserver.DefaultOptions()
+	if viper.IsSet("group-commit-max-batch") {
+		srvOpts.GroupCommitMaxBatch = viper.GetInt("group-commit-max-batch")
+	}
+	if viper.IsSet("group-commit-max-delay") {
+		srvOpts.GroupCommitMaxDelay = viper.GetDuration("group-commit-max-delay")
+	}
+	if viper.IsSet("request-parallelism") {
+		srvOpts.RequestParallelism = viper.GetInt("request-parallelism")
+	}
+	if viper.IsSet("max-read-wait") {
+		srvOpts.MaxReadWait = viper.GetDuration("max-read-wait")
+	}
+	storageSrv := storageserver.NewStorageServer(logImpl, ms, srvOpts)
 
-	lis, err := net.Listen("tcp", ":50051")
+	listen := viper.GetString("listen")
+	lis, err := net.Listen("tcp", listen)
 	if err != nil {
 		log.Fatalf("listen error: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	streamsCtx, stopStreams := context.WithCancel(context.Background())
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(stopOnShutdown(streamsCtx)))
 	storagepb.RegisterStorageServer(grpcServer, storageSrv)
+	if viper.GetBool("serve-log") {
+		// 远端只能裁掉本地 gc 也已经不需要的记录
+		remotelog.Register(grpcServer, logImpl, collector.LowGSN)
+	}
 
-	log.Println("storage gRPC server listening on :50051")
-	if err := grpcServer.Serve(lis); err != nil {
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- grpcServer.Serve(lis) }()
+	log.Printf("storage gRPC server listening on %s (log backend %s)", listen, backend)
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-serveErr:
 		log.Fatalf("serve error: %v", err)
+	case sig := <-sigC:
+		log.Printf("received %v, shutting down", sig)
+	}
+
+	// 先停止接收新请求并等正在进行的 MultiPut 完成，再停后台任务、写最后一个 checkpoint、关闭日志
+	stopStreams()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(viper.GetDuration("shutdown-timeout")):
+		log.Printf("shutdown: in-flight requests did not finish in time, stopping")
+		grpcServer.Stop()
+	}
+	stopBackground()
+	collector.Stop()
+	if checkpointer != nil {
+		if err := checkpointer.Stop(); err != nil {
+			log.Printf("shutdown: final checkpoint: %v", err)
+		}
+	}
+	if err := logImpl.Close(); err != nil {
+		log.Printf("shutdown: closing log: %v", err)
 	}
+	log.Printf("shutdown complete")
 }
+
+// logStats periodically logs the read cache and Scalog client pool counters.
+func logStats(ctx context.Context, interval time.Duration, cache *cachelog.CachedLog, l sharedlog.SharedLog) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if cache != nil {
+				log.Printf("stats: read cache %+v", cache.Stats())
+			}
+			if sl, ok := l.(*scalog.ScalogSystem); ok {
+				log.Printf("stats: scalog pool %+v", sl.Stats())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// longLivedStreams never end on their own, so GracefulStop would wait for
+// them forever.
+var longLivedStreams = map[string]bool{
+	storagepb.Storage_Watch_FullMethodName:   true,
+	storagepb.Storage_Changes_FullMethodName: true,
+	logpb.Log_Subscribe_FullMethodName:       true,
+}
+
+// stopOnShutdown ends the long-lived streams with Unavailable once ctx is
+// done, so that clients reconnect elsewhere and resume.
+func stopOnShutdown(ctx context.Context) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !longLivedStreams[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		streamCtx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+		err := handler(srv, &ctxStream{ServerStream: ss, ctx: streamCtx})
+		if ctx.Err() != nil && ss.Context().Err() == nil {
+			return status.Error(codes.Unavailable, "server shutting down")
+		}
+		return err
+	}
+}
+
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,175 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/chn0318/logstore/checkpoint"
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/recovery"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+type Options struct {
+	// Interval between GC runs.
+	Interval time.Duration
+	// SnapshotRetention is how many GSNs below StableGSN snapshot reads stay
+	// servable; older versions are pruned.
+	SnapshotRetention uint64
+	// NoRecovery is set when the map is never recovered from the log, e.g.
+	// on the memory backend, which starts empty. The log is then trimmed
+	// without a checkpoint.
+	NoRecovery bool
+}
+
+// Result describes one GC run.
+type Result struct {
+	Horizon        uint64
+	PrunedVersions int
+	// LowGSN is the lowest GSN still needed; everything below it may be
+	// trimmed. Zero means nothing could be trimmed this run.
+	LowGSN  uint64
+	Trimmed bool
+}
+
+// Collector prunes old key versions from the map service and trims the log
+// below the lowest GSN still needed, which is the minimum of
+//   - the oldest DATA record referenced by a retained version,
+//   - the first GSN after the last checkpoint (recovery replays from there),
+//   - the first GSN after StableGSN (covers in-flight MultiPuts),
+//   - the replay cursor while a commit of unknown outcome awaits a replay.
+//
+// Without a checkpoint nothing is trimmed, since recovery would need the
+// whole log, unless Options.NoRecovery is set.
+type Collector struct {
+	log  sharedlog.SharedLog
+	ms   *mapservice.MapService
+	ckpt *checkpoint.Checkpointer
+	opts Options
+
+	mu         sync.Mutex
+	lowGSN     uint64
+	noTrimOnce sync.Once
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+// NewCollector creates a collector. With a nil ckpt it only prunes versions
+// and never trims the log, unless Options.NoRecovery is set.
+func NewCollector(l sharedlog.SharedLog, ms *mapservice.MapService, ckpt *checkpoint.Checkpointer, opts Options) *Collector {
+	return &Collector{
+		log:   l,
+		ms:    ms,
+		ckpt:  ckpt,
+		opts:  opts,
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+}
+
+// Start runs GC in the background every Options.Interval.
+func (c *Collector) Start() {
+	go c.run()
+}
+
+func (c *Collector) run() {
+	defer close(c.doneC)
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.RunOnce(context.Background()); err != nil {
+				log.Printf("gc: %v", err)
+			}
+		case <-c.stopC:
+			return
+		}
+	}
+}
+
+// Stop ends the background loop.
+func (c *Collector) Stop() {
+	close(c.stopC)
+	<-c.doneC
+}
+
+// LowGSN returns the lowest needed GSN computed by the last run.
+func (c *Collector) LowGSN() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lowGSN
+}
+
+// RunOnce performs one GC pass: catch up with the log, prune versions,
+// checkpoint (so that the checkpoint on disk no longer references pruned
+// data), then trim.
+func (c *Collector) RunOnce(ctx context.Context) (Result, error) {
+	var res Result
+
+	// 追上日志后 replay 游标才会越过 tombstone，Prune 才能把删掉的 key 整个去掉
+	if _, err := recovery.CatchUp(ctx, c.log, c.ms); err != nil {
+		log.Printf("gc: catch-up: %v", err)
+	}
+
+	stable := c.ms.StableGSN()
+	if stable > c.opts.SnapshotRetention {
+		res.Horizon = stable - c.opts.SnapshotRetention
+		res.PrunedVersions = c.ms.Prune(res.Horizon)
+	}
+
+	low := uint64(math.MaxUint64)
+	if !c.opts.NoRecovery {
+		if c.ckpt == nil {
+			return res, nil
+		}
+		if err := c.ckpt.Checkpoint(); err != nil {
+			return res, err
+		}
+		ckptGSN, saved := c.ckpt.LastGSN()
+		if !saved {
+			return res, nil
+		}
+		low = ckptGSN + 1
+	}
+	if s := c.ms.StableGSN() + 1; s < low {
+		low = s
+	}
+	if d, ok := c.ms.MinDataGSN(); ok && d < low {
+		low = d
+	}
+	// 结果未知的 commit 重放之前不能裁掉
+	if c.ms.NeedsReplay() {
+		if r := c.ms.ReplayNext(); r < low {
+			low = r
+		}
+	}
+	res.LowGSN = low
+
+	c.mu.Lock()
+	c.lowGSN = low
+	c.mu.Unlock()
+
+	if low <= c.log.Head(ctx) {
+		return res, nil
+	}
+	err := c.log.Trim(ctx, low)
+	if errors.Is(err, sharedlog.ErrTrimNotSupported) {
+		c.noTrimOnce.Do(func() { log.Printf("gc: log backend does not support trim, only pruning versions") })
+		return res, nil
+	}
+	if errors.Is(err, sharedlog.ErrNotLeader) {
+		// the leader's collector trims for the whole cluster
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Trimmed = true
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package gc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// put writes key to l and applies the commit to ms, and returns the GSN of
+// the DATA record.
+func put(t *testing.T, l sharedlog.SharedLog, ms *mapservice.MapService, key string) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	ref, err := l.AppendData(ctx, sharedlog.DataRecord{Key: key, Value: []byte("v")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []sharedlog.CommitEntry{{Key: key, Ref: ref}}
+	gsn, err := l.AppendCommit(ctx, sharedlog.CommitRecord{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms.ApplyCommit(gsn, mapservice.EntriesFromLog(entries))
+	return ref.GSN
+}
+
+func TestNoRecoveryTrims(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name       string
+		noRecovery bool
+		trims      bool
+	}{
+		{"no checkpoint", false, false},
+		{"no recovery needed", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := memorylog.NewMemoryLog()
+			ms := mapservice.NewMapService()
+			put(t, l, ms, "a")
+			var b uint64
+			for i := 0; i < 3; i++ {
+				b = put(t, l, ms, "b")
+			}
+			put(t, l, ms, "a")
+			c := NewCollector(l, ms, nil, Options{NoRecovery: tc.noRecovery})
+
+			res, err := c.RunOnce(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Trimmed != tc.trims {
+				t.Fatalf("Trimmed = %v, want %v", res.Trimmed, tc.trims)
+			}
+			if !tc.trims {
+				if head := l.Head(ctx); head != 1 {
+					t.Fatalf("head = %d without a checkpoint, want 1", head)
+				}
+				return
+			}
+			// the old versions are pruned, so the oldest DATA still
+			// referenced is the last one of b
+			if head := l.Head(ctx); head != b || res.LowGSN != b || c.LowGSN() != b {
+				t.Fatalf("head = %d, LowGSN = %d, %d, want %d", head, res.LowGSN, c.LowGSN(), b)
+			}
+		})
+	}
+}
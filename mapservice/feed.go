@@ -0,0 +1,116 @@
+package mapservice
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Change is one applied commit as delivered to watchers.
+type Change struct {
+	CommitGSN uint64
+	Entries   []CommitEntry
+}
+
+// ErrWatcherLagged is reported by a Watcher that was dropped because it did
+// not keep up with the commit rate.
+var ErrWatcherLagged = errors.New("mapservice: watcher fell behind")
+
+// feed hands applied commits to watchers in GSN order. ApplyCommit may run
+// out of GSN order, so a commit is held back until StableGSN reaches it.
+type feed struct {
+	mu sync.Mutex
+	// applied commits with a GSN above released
+	held     map[uint64][]CommitEntry
+	released uint64
+	watchers map[*Watcher]struct{}
+}
+
+// Watcher receives every commit with a GSN above From(), in GSN order.
+type Watcher struct {
+	s    *MapService
+	c    chan Change
+	from uint64
+	err  error
+}
+
+// Watch registers a watcher whose channel buffers up to buf changes. A
+// watcher that would block is dropped: its channel is closed and Err returns
+// ErrWatcherLagged. Call Close when done.
+func (s *MapService) Watch(buf int) *Watcher {
+	f := &s.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &Watcher{s: s, c: make(chan Change, buf), from: f.released}
+	f.watchers[w] = struct{}{}
+	return w
+}
+
+// C delivers the changes. It is closed by Close or when the watcher lags.
+func (w *Watcher) C() <-chan Change { return w.c }
+
+// From is the GSN after which this watcher's changes start; commits up to
+// it had already been released when the watcher registered.
+func (w *Watcher) From() uint64 { return w.from }
+
+// Err returns ErrWatcherLagged once C is closed because the watcher lagged.
+func (w *Watcher) Err() error {
+	f := &w.s.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return w.err
+}
+
+func (w *Watcher) Close() {
+	f := &w.s.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.watchers[w]; ok {
+		delete(f.watchers, w)
+		close(w.c)
+	}
+}
+
+// hold records an applied commit for release. Commits at or below released
+// were delivered already (re-applies during catch-up) and are ignored.
+func (s *MapService) hold(commitGSN uint64, entries []CommitEntry) {
+	f := &s.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if commitGSN > f.released {
+		f.held[commitGSN] = entries
+	}
+}
+
+// releaseChanges delivers the held commits up to StableGSN. It must not be
+// called with s.mu or s.wmMu held.
+func (s *MapService) releaseChanges() {
+	f := &s.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stable := s.StableGSN()
+	if stable <= f.released {
+		return
+	}
+	gsns := make([]uint64, 0, len(f.held))
+	for gsn := range f.held {
+		if gsn <= stable {
+			gsns = append(gsns, gsn)
+		}
+	}
+	sort.Slice(gsns, func(i, j int) bool { return gsns[i] < gsns[j] })
+	for _, gsn := range gsns {
+		ch := Change{CommitGSN: gsn, Entries: f.held[gsn]}
+		delete(f.held, gsn)
+		for w := range f.watchers {
+			select {
+			case w.c <- ch:
+			default:
+				w.err = ErrWatcherLagged
+				delete(f.watchers, w)
+				close(w.c)
+			}
+		}
+	}
+	f.released = stable
+}
@@ -0,0 +1,77 @@
+package mapservice
+
+import (
+	"errors"
+	"testing"
+)
+
+// drain returns the GSNs of the changes buffered in w.
+func drain(w *Watcher) []uint64 {
+	var gsns []uint64
+	for {
+		select {
+		case ch, ok := <-w.C():
+			if !ok {
+				return gsns
+			}
+			gsns = append(gsns, ch.CommitGSN)
+		default:
+			return gsns
+		}
+	}
+}
+
+func TestWatchOrder(t *testing.T) {
+	s := NewMapService()
+	s.ApplyCommit(1, []CommitEntry{put("a", 1)})
+	w := s.Watch(16)
+	defer w.Close()
+	if w.From() != 1 {
+		t.Fatalf("From = %d, want 1", w.From())
+	}
+
+	// two requests in flight; the one with the larger GSN applies first
+	slow, fast := s.BeginCommit(), s.BeginCommit()
+	fast.Apply(3, []CommitEntry{put("b", 3)})
+	if got := drain(w); len(got) != 0 {
+		t.Fatalf("released %v while commit 2 is in flight", got)
+	}
+	slow.Apply(2, []CommitEntry{put("a", 2)})
+	if got := drain(w); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("changes = %v, want [2 3]", got)
+	}
+
+	// a re-applied commit is not delivered twice
+	s.ApplyCommit(2, []CommitEntry{put("a", 2)})
+	if got := drain(w); len(got) != 0 {
+		t.Fatalf("re-applied commit delivered again: %v", got)
+	}
+
+	// a later watcher starts after what was released
+	w2 := s.Watch(16)
+	defer w2.Close()
+	s.ApplyCommit(4, []CommitEntry{put("c", 4)})
+	if w2.From() != 3 {
+		t.Fatalf("From = %d, want 3", w2.From())
+	}
+	if got := drain(w2); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("changes of the later watcher = %v, want [4]", got)
+	}
+}
+
+func TestWatchLagged(t *testing.T) {
+	s := NewMapService()
+	w := s.Watch(1)
+	s.ApplyCommit(1, []CommitEntry{put("a", 1)})
+	s.ApplyCommit(2, []CommitEntry{put("a", 2)})
+	if got := drain(w); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("changes = %v, want [1] before the watcher was dropped", got)
+	}
+	if _, ok := <-w.C(); ok {
+		t.Fatal("channel of a lagging watcher is open")
+	}
+	if !errors.Is(w.Err(), ErrWatcherLagged) {
+		t.Fatalf("Err = %v, want ErrWatcherLagged", w.Err())
+	}
+	w.Close()
+}
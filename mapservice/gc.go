@@ -0,0 +1,80 @@
+package mapservice
+
+// Prune drops versions that no snapshot read at a GSN >= horizon can see:
+// for each key, everything older than its newest version with
+// CommitGSN <= horizon. A key whose only remaining version is such a
+// tombstone is removed entirely once the tombstone is below ReplayNext();
+// until then a catch-up may still apply an older commit of another server,
+// which the tombstone has to hide. horizon must not exceed StableGSN(),
+// otherwise a late commit could resurrect a removed key.
+//
+// It returns the number of versions dropped.
+func (s *MapService) Prune(horizon uint64) int {
+	replayNext := s.ReplayNext()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if horizon <= s.horizon {
+		return 0
+	}
+	s.horizon = horizon
+
+	type change struct {
+		key      string
+		versions []KeyMeta // nil means delete the key
+	}
+	var changes []change
+	pruned := 0
+	s.m.ascend("", "", func(it indexItem) bool {
+		i := len(it.versions) - 1
+		for i >= 0 && it.versions[i].CommitGSN > horizon {
+			i--
+		}
+		switch {
+		case i < 0:
+			// every version is newer than horizon
+		case i == len(it.versions)-1 && it.versions[i].Deleted && it.versions[i].CommitGSN < replayNext:
+			pruned += len(it.versions)
+			changes = append(changes, change{key: it.key})
+		case i > 0:
+			pruned += i
+			changes = append(changes, change{key: it.key, versions: append([]KeyMeta(nil), it.versions[i:]...)})
+		}
+		return true
+	})
+	for _, c := range changes {
+		if c.versions == nil {
+			s.m.delete(c.key)
+		} else {
+			s.m.set(c.key, c.versions)
+		}
+	}
+	return pruned
+}
+
+// SnapshotHorizon returns the oldest GSN snapshot reads are guaranteed to
+// see correctly; older versions may have been pruned.
+func (s *MapService) SnapshotHorizon() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.horizon
+}
+
+// MinDataGSN returns the smallest data GSN referenced by any retained
+// version, i.e. the oldest DATA record a read may still need. ok is false if
+// no version references data.
+func (s *MapService) MinDataGSN() (min uint64, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.m.ascend("", "", func(it indexItem) bool {
+		for _, v := range it.versions {
+			if v.Deleted {
+				continue
+			}
+			if !ok || v.Ref.GSN < min {
+				min, ok = v.Ref.GSN, true
+			}
+		}
+		return true
+	})
+	return min, ok
+}
@@ -0,0 +1,110 @@
+package mapservice
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// replayed moves the replay cursor of s to next.
+func replayed(t *testing.T, s *MapService, next uint64) {
+	t.Helper()
+	r, err := s.BeginReplay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Advance(next)
+	r.Done(true)
+}
+
+func TestPrune(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		commits map[uint64][]CommitEntry
+		horizon uint64
+		// replayNext is the replay cursor when Prune runs
+		replayNext uint64
+		// want is the GSNs of the commits left in the chain of "k"; nil if
+		// the key is gone
+		want   []uint64
+		pruned int
+	}{
+		{"all newer than the horizon", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {put("k", 19)}}, 5, 0, []uint64{10, 20}, 0},
+		{"keeps the version the horizon sees", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {put("k", 19)}, 30: {put("k", 29)}}, 25, 0, []uint64{20, 30}, 1},
+		{"horizon on a version", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {put("k", 19)}}, 20, 0, []uint64{20}, 1},
+		{"tombstone below the horizon", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {del("k")}}, 25, 21, nil, 2},
+		{"tombstone not replayed past", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {del("k")}}, 25, 20, []uint64{20}, 1},
+		{"tombstone above the horizon", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {del("k")}}, 15, 21, []uint64{10, 20}, 0},
+		{"write after a tombstone", map[uint64][]CommitEntry{10: {put("k", 9)}, 20: {del("k")}, 30: {put("k", 29)}}, 25, 31, []uint64{20, 30}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewMapService()
+			for gsn, entries := range tc.commits {
+				s.ApplyCommit(gsn, entries)
+			}
+			replayed(t, s, tc.replayNext)
+			if n := s.Prune(tc.horizon); n != tc.pruned {
+				t.Fatalf("Prune(%d) = %d, want %d", tc.horizon, n, tc.pruned)
+			}
+			var got []uint64
+			if it, ok := s.m.get("k"); ok {
+				for _, v := range it.versions {
+					got = append(got, v.CommitGSN)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("versions after Prune(%d) = %v, want %v", tc.horizon, got, tc.want)
+			}
+			if h := s.SnapshotHorizon(); h != tc.horizon {
+				t.Fatalf("SnapshotHorizon = %d, want %d", h, tc.horizon)
+			}
+			// a lower horizon is a no-op
+			if n := s.Prune(tc.horizon - 1); n != 0 {
+				t.Fatalf("Prune below the horizon dropped %d versions", n)
+			}
+		})
+	}
+}
+
+func TestMinDataGSN(t *testing.T) {
+	s := NewMapService()
+	if _, ok := s.MinDataGSN(); ok {
+		t.Fatal("MinDataGSN of an empty map is set")
+	}
+	s.ApplyCommit(10, []CommitEntry{put("a", 7), put("b", 9)})
+	s.ApplyCommit(20, []CommitEntry{put("a", 19), del("b")})
+	if min, ok := s.MinDataGSN(); !ok || min != 7 {
+		t.Fatalf("MinDataGSN = %d, %v, want 7", min, ok)
+	}
+	replayed(t, s, 21)
+	s.Prune(20)
+	// b is gone, a only keeps its version at 20
+	if min, ok := s.MinDataGSN(); !ok || min != 19 {
+		t.Fatalf("MinDataGSN after Prune = %d, %v, want 19", min, ok)
+	}
+	if metas := s.GetMetas([]string{"a", "b"}); !reflect.DeepEqual(metas, map[string]KeyMeta{"a": {Ref: sharedlog.RecordRef{GSN: 19}, CommitGSN: 20}}) {
+		t.Fatalf("GetMetas after Prune = %v", metas)
+	}
+}
+
+func TestPruneThenOlderCommit(t *testing.T) {
+	s := NewMapService()
+	s.ApplyCommit(10, []CommitEntry{put("k", 9)})
+	s.ApplyCommit(20, []CommitEntry{del("k")})
+	s.Prune(25)
+
+	// a catch-up brings in another server's older write of k
+	s.ApplyCommit(15, []CommitEntry{put("k", 14)})
+	if meta := s.GetMetas([]string{"k"})["k"]; !meta.Deleted || meta.CommitGSN != 20 {
+		t.Fatalf("k = %+v after an older commit, want the tombstone at 20", meta)
+	}
+
+	// once the replay has passed the tombstone nothing older can arrive
+	replayed(t, s, 26)
+	s.Prune(26)
+	if metas := s.GetMetas([]string{"k"}); len(metas) != 0 {
+		t.Fatalf("GetMetas = %v, want k removed", metas)
+	}
+}
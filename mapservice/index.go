@@ -0,0 +1,87 @@
+package mapservice
+
+import (
+	"sort"
+
+	"github.com/google/btree"
+)
+
+const btreeDegree = 32
+
+// indexItem holds every retained version of a key, oldest first. Versions
+// are ordered by CommitGSN.
+type indexItem struct {
+	key      string
+	versions []KeyMeta
+}
+
+func lessItem(a, b indexItem) bool { return a.key < b.key }
+
+func (it indexItem) latest() KeyMeta { return it.versions[len(it.versions)-1] }
+
+// at returns the newest version with CommitGSN <= gsn.
+func (it indexItem) at(gsn uint64) (KeyMeta, bool) {
+	i := sort.Search(len(it.versions), func(i int) bool { return it.versions[i].CommitGSN > gsn })
+	if i == 0 {
+		return KeyMeta{}, false
+	}
+	return it.versions[i-1], true
+}
+
+// keyIndex is the ordered key -> version chain index behind MapService. It
+// is not safe for concurrent use; MapService.mu protects it.
+type keyIndex struct {
+	t *btree.BTreeG[indexItem]
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{t: btree.NewG(btreeDegree, lessItem)}
+}
+
+func (x *keyIndex) get(key string) (indexItem, bool) {
+	return x.t.Get(indexItem{key: key})
+}
+
+// addVersion inserts meta into key's chain at its CommitGSN position. A
+// chain that already has a version with the same CommitGSN is left alone.
+func (x *keyIndex) addVersion(key string, meta KeyMeta) {
+	it, ok := x.t.Get(indexItem{key: key})
+	if !ok {
+		x.t.ReplaceOrInsert(indexItem{key: key, versions: []KeyMeta{meta}})
+		return
+	}
+	i := sort.Search(len(it.versions), func(i int) bool { return it.versions[i].CommitGSN >= meta.CommitGSN })
+	if i < len(it.versions) && it.versions[i].CommitGSN == meta.CommitGSN {
+		return
+	}
+	if i == len(it.versions) {
+		// 绝大多数 commit 都比已有版本新，直接追加
+		x.t.ReplaceOrInsert(indexItem{key: key, versions: append(it.versions, meta)})
+		return
+	}
+	versions := make([]KeyMeta, 0, len(it.versions)+1)
+	versions = append(versions, it.versions[:i]...)
+	versions = append(versions, meta)
+	versions = append(versions, it.versions[i:]...)
+	x.t.ReplaceOrInsert(indexItem{key: key, versions: versions})
+}
+
+func (x *keyIndex) set(key string, versions []KeyMeta) {
+	x.t.ReplaceOrInsert(indexItem{key: key, versions: versions})
+}
+
+func (x *keyIndex) delete(key string) {
+	x.t.Delete(indexItem{key: key})
+}
+
+func (x *keyIndex) len() int { return x.t.Len() }
+
+// ascend calls fn for keys in [start, end) in order until fn returns false.
+// An empty end means no upper bound.
+func (x *keyIndex) ascend(start, end string, fn func(it indexItem) bool) {
+	if end == "" {
+		x.t.AscendGreaterOrEqual(indexItem{key: start}, fn)
+		return
+	}
+	x.t.AscendRange(indexItem{key: start}, indexItem{key: end}, fn)
+}
@@ -8,55 +8,101 @@ import (
 
 // KeyMeta stores the latest data GSN and the commit GSN
 // that last updated this key.
+// Deleted 表示最后一次 commit 是删除；保留 CommitGSN 以便旧的 commit 不会把 key “复活”。
 type KeyMeta struct {
 	Ref       sharedlog.RecordRef
 	CommitGSN uint64
+	Deleted   bool `json:",omitempty"`
 }
 
 // CommitEntry describes a single key->data_gsn pair inside a commit.
 // Coordinator 可以把 sharedlog.CommitEntry 转成这个类型传进来。
 type CommitEntry struct {
-	Key string
-	Ref sharedlog.RecordRef
+	Key       string
+	Ref       sharedlog.RecordRef
+	Tombstone bool
+}
+
+// EntriesFromLog converts the entries of a sharedlog.CommitRecord.
+func EntriesFromLog(entries []sharedlog.CommitEntry) []CommitEntry {
+	res := make([]CommitEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, CommitEntry{
+			Key:       e.Key,
+			Ref:       e.Ref,
+			Tombstone: e.Tombstone,
+		})
+	}
+	return res
 }
 
 // MapService is an in-memory implementation of the mapping service.
-// It maintains a mapping from key to (data_gsn, commit_gsn).
+// It maintains an ordered mapping from key to its version chain of
+// (data_gsn, commit_gsn), so reads can be served as of an earlier commit.
 type MapService struct {
 	mu sync.RWMutex
-	m  map[string]KeyMeta
+	m  *keyIndex
 
 	// 记录 map-service 已经处理过的最大 commit_gsn（方便以后做 checkpoint/recover）
 	maxCommitGSN uint64
+	// horizon 之前的版本可能已经被 GC 掉，快照读只能读 >= horizon 的 GSN
+	horizon uint64
+	// 每个 client 最后一次 commit 的请求，用来给重试的 MultiPut 去重（见 session.go）
+	sessions map[string]ClientSession
+
+	// 正在进行中的 commit（见 watermark.go），用来计算 StableGSN
+	wmMu          sync.Mutex
+	pending       map[uint64]uint64 // id -> GSN lower bound
+	nextPendingID uint64
+	wmChanged     chan struct{}
+	// 从日志重放的进度（见 replay.go）：replayNext 之前的 commit 都已经在 map 里；
+	// unknown 表示有结果未知的 commit（append 报错但可能已经写进日志）还没被重放覆盖
+	replayNext uint64
+	unknown    bool
+	replayGen  uint64
+	replaySem  chan struct{}
+
+	// 已 apply 的 commit 按 GSN 顺序推给 Watch 的订阅者（见 feed.go）
+	feed feed
 }
 
 // New creates a new in-memory MapService.
 func NewMapService() *MapService {
 	return &MapService{
-		m: make(map[string]KeyMeta),
+		m:         newKeyIndex(),
+		sessions:  make(map[string]ClientSession),
+		pending:   make(map[uint64]uint64),
+		wmChanged: make(chan struct{}),
+		replaySem: make(chan struct{}, 1),
+		feed: feed{
+			held:     make(map[uint64][]CommitEntry),
+			watchers: make(map[*Watcher]struct{}),
+		},
 	}
 }
 
 // ApplyCommit applies a commit record atomically.
 //
-// For each key in entries, it compares the commitGSN with the key's current
-// CommitGSN. If commitGSN is larger, it updates the mapping to the new DataGSN.
-// If commitGSN is smaller or equal, the update for that key is skipped.
+// For each key in entries, a new version tagged with commitGSN is added to
+// the key's chain at its GSN position. Reads of the latest state see the
+// version with the largest CommitGSN, so a commit that arrives late does not
+// override a newer one. Re-applying the same commitGSN is a no-op.
 //
 // 整个函数在一个写锁下执行，保证“原子地应用这一次 commit”。
 func (s *MapService) ApplyCommit(commitGSN uint64, entries []CommitEntry) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// 先交给 feed，再推进 maxCommitGSN，这样 StableGSN 越过它之前它一定已经在 feed 里
+	s.hold(commitGSN, entries)
 
+	s.mu.Lock()
 	if commitGSN > s.maxCommitGSN {
 		s.maxCommitGSN = commitGSN
 	}
 	for _, e := range entries {
-		meta, ok := s.m[e.Key]
-		if !ok || commitGSN > meta.CommitGSN {
-			s.m[e.Key] = KeyMeta{Ref: e.Ref, CommitGSN: commitGSN}
-		}
+		s.m.addVersion(e.Key, KeyMeta{Ref: e.Ref, CommitGSN: commitGSN, Deleted: e.Tombstone})
 	}
+	s.mu.Unlock()
+
+	s.notifyWatermark()
 }
 
 func (s *MapService) GetOffsets(keys []string) map[string]sharedlog.RecordRef {
@@ -64,13 +110,87 @@ func (s *MapService) GetOffsets(keys []string) map[string]sharedlog.RecordRef {
 	defer s.mu.RUnlock()
 	res := make(map[string]sharedlog.RecordRef, len(keys))
 	for _, k := range keys {
-		if meta, ok := s.m[k]; ok {
-			res[k] = meta.Ref
+		if it, ok := s.m.get(k); ok && !it.latest().Deleted {
+			res[k] = it.latest().Ref
+		}
+	}
+	return res
+}
+
+// GetMetas returns the full KeyMeta of every key in keys that has ever been
+// committed, including deleted ones (Deleted=true).
+func (s *MapService) GetMetas(keys []string) map[string]KeyMeta {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make(map[string]KeyMeta, len(keys))
+	for _, k := range keys {
+		if it, ok := s.m.get(k); ok {
+			res[k] = it.latest()
+		}
+	}
+	return res
+}
+
+// GetMetasAt is GetMetas as of commit atGSN: for each key it returns the
+// newest version with CommitGSN <= atGSN. Keys with no such version are
+// left out. The caller must make sure every commit <= atGSN has been
+// applied, otherwise the result may miss one.
+func (s *MapService) GetMetasAt(keys []string, atGSN uint64) map[string]KeyMeta {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make(map[string]KeyMeta, len(keys))
+	for _, k := range keys {
+		it, ok := s.m.get(k)
+		if !ok {
+			continue
+		}
+		if meta, ok := it.at(atGSN); ok {
+			res[k] = meta
 		}
 	}
 	return res
 }
 
+// ScanItem is one live key returned by Scan.
+type ScanItem struct {
+	Key  string
+	Meta KeyMeta
+}
+
+// Scan returns up to limit live (not deleted) keys in [start, end) in key
+// order. An empty end means no upper bound; limit <= 0 means no limit.
+// more reports whether further keys exist past the last one returned.
+func (s *MapService) Scan(start, end string, limit int) (items []ScanItem, more bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.m.ascend(start, end, func(it indexItem) bool {
+		meta := it.latest()
+		if meta.Deleted {
+			return true
+		}
+		if limit > 0 && len(items) == limit {
+			more = true
+			return false
+		}
+		items = append(items, ScanItem{Key: it.key, Meta: meta})
+		return true
+	})
+	return items, more
+}
+
+// PrefixEnd returns the smallest key greater than every key with the given
+// prefix, or "" if there is none (the prefix is all 0xff bytes).
+func PrefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
 // MaxCommitGSN returns the largest commit GSN that has been applied so far.
 // 以后做 checkpoint / recovery 时会用到这个值。
 func (s *MapService) MaxCommitGSN() uint64 {
@@ -78,3 +198,68 @@ func (s *MapService) MaxCommitGSN() uint64 {
 	defer s.mu.RUnlock()
 	return s.maxCommitGSN
 }
+
+// Snapshot is a point-in-time copy of the mapping, used for checkpoints.
+// Keys maps each key to its version chain, oldest first. Every commit with
+// a GSN <= MaxCommitGSN is included; later commits may be partially
+// included, and replaying them again is harmless.
+type Snapshot struct {
+	Keys         map[string][]KeyMeta
+	MaxCommitGSN uint64
+	Horizon      uint64                   `json:",omitempty"`
+	Sessions     map[string]ClientSession `json:",omitempty"`
+}
+
+// Snapshot copies the current mapping under the read lock, so the result
+// reflects a state between two ApplyCommit calls.
+func (s *MapService) Snapshot() Snapshot {
+	stable := s.StableGSN()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make(map[string][]KeyMeta, s.m.len())
+	s.m.ascend("", "", func(it indexItem) bool {
+		keys[it.key] = append([]KeyMeta(nil), it.versions...)
+		return true
+	})
+	sessions := make(map[string]ClientSession, len(s.sessions))
+	for id, cs := range s.sessions {
+		sessions[id] = cs
+	}
+	return Snapshot{
+		Keys:         keys,
+		MaxCommitGSN: stable,
+		Horizon:      s.horizon,
+		Sessions:     sessions,
+	}
+}
+
+// Restore replaces the whole mapping with snap. It is meant to be called
+// during recovery, before any commit is applied.
+func (s *MapService) Restore(snap Snapshot) {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.wmMu.Lock()
+	s.replayNext = snap.MaxCommitGSN + 1
+	s.wmMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m = newKeyIndex()
+	s.maxCommitGSN = snap.MaxCommitGSN
+	s.horizon = snap.Horizon
+	s.sessions = make(map[string]ClientSession, len(snap.Sessions))
+	for id, cs := range snap.Sessions {
+		s.sessions[id] = cs
+	}
+	for k, versions := range snap.Keys {
+		if len(versions) == 0 {
+			continue
+		}
+		s.m.set(k, append([]KeyMeta(nil), versions...))
+		if last := versions[len(versions)-1].CommitGSN; last > s.maxCommitGSN {
+			s.maxCommitGSN = last
+		}
+	}
+	// 快照里的 commit 不再推给 watcher
+	s.feed.held = make(map[uint64][]CommitEntry)
+	s.feed.released = s.maxCommitGSN
+}
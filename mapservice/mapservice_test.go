@@ -0,0 +1,62 @@
+package mapservice
+
+import (
+	"testing"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+func put(key string, dataGSN uint64) CommitEntry {
+	return CommitEntry{Key: key, Ref: sharedlog.RecordRef{GSN: dataGSN}}
+}
+
+func del(key string) CommitEntry {
+	return CommitEntry{Key: key, Tombstone: true}
+}
+
+func TestSnapshotReads(t *testing.T) {
+	s := NewMapService()
+	// commits arrive out of GSN order, and 20 twice
+	s.ApplyCommit(20, []CommitEntry{put("a", 19), put("b", 18)})
+	s.ApplyCommit(10, []CommitEntry{put("a", 9)})
+	s.ApplyCommit(30, []CommitEntry{del("a")})
+	s.ApplyCommit(20, []CommitEntry{put("a", 99)})
+
+	for _, tc := range []struct {
+		name string
+		at   uint64 // 0 reads the latest state
+		key  string
+		want KeyMeta
+		ok   bool
+	}{
+		{"before the first commit", 9, "a", KeyMeta{}, false},
+		{"first version", 10, "a", KeyMeta{Ref: sharedlog.RecordRef{GSN: 9}, CommitGSN: 10}, true},
+		{"between commits", 15, "a", KeyMeta{Ref: sharedlog.RecordRef{GSN: 9}, CommitGSN: 10}, true},
+		{"re-applied commit unchanged", 20, "a", KeyMeta{Ref: sharedlog.RecordRef{GSN: 19}, CommitGSN: 20}, true},
+		{"tombstone", 30, "a", KeyMeta{CommitGSN: 30, Deleted: true}, true},
+		{"latest is the tombstone", 0, "a", KeyMeta{CommitGSN: 30, Deleted: true}, true},
+		{"other key", 25, "b", KeyMeta{Ref: sharedlog.RecordRef{GSN: 18}, CommitGSN: 20}, true},
+		{"other key before it was written", 19, "b", KeyMeta{}, false},
+		{"missing key", 30, "c", KeyMeta{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var metas map[string]KeyMeta
+			if tc.at == 0 {
+				metas = s.GetMetas([]string{tc.key})
+			} else {
+				metas = s.GetMetasAt([]string{tc.key}, tc.at)
+			}
+			got, ok := metas[tc.key]
+			if ok != tc.ok || got != tc.want {
+				t.Fatalf("meta of %q at %d = %+v, %v, want %+v, %v", tc.key, tc.at, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+
+	if got := s.MaxCommitGSN(); got != 30 {
+		t.Fatalf("MaxCommitGSN = %d, want 30", got)
+	}
+	if refs := s.GetOffsets([]string{"a", "b"}); len(refs) != 1 || refs["b"].GSN != 18 {
+		t.Fatalf("GetOffsets = %v, want only b, deleted a left out", refs)
+	}
+}
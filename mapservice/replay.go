@@ -0,0 +1,73 @@
+package mapservice
+
+import "context"
+
+// Replay is one pass that applies commits read from the log to the map. It
+// moves the replay cursor: every commit in the log below ReplayNext has
+// been applied. Commits made through this map service do not move it, since
+// other servers sharing the log may have committed below them.
+//
+// Only one Replay runs at a time; Done must be called when it ends.
+type Replay struct {
+	s   *MapService
+	gen uint64
+}
+
+// BeginReplay waits until no other replay is running, or ctx is done, and
+// starts one. The log's tail must be read after it returns, so that the
+// replay covers every commit of unknown outcome aborted before.
+func (s *MapService) BeginReplay(ctx context.Context) (*Replay, error) {
+	select {
+	case s.replaySem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	return &Replay{s: s, gen: s.replayGen}, nil
+}
+
+// Next returns the first GSN the replay has to read.
+func (r *Replay) Next() uint64 {
+	return r.s.ReplayNext()
+}
+
+// Advance notes that every commit below next has been applied. Replays call
+// it after each commit, so that progress is kept even if they stop midway.
+func (r *Replay) Advance(next uint64) {
+	s := r.s
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	if next > s.replayNext {
+		s.replayNext = next
+	}
+}
+
+// Done ends the replay. reachedTail reports whether it read the log up to a
+// tail taken after BeginReplay; if so, the commits of unknown outcome
+// aborted before BeginReplay have been replayed.
+func (r *Replay) Done(reachedTail bool) {
+	s := r.s
+	s.wmMu.Lock()
+	if reachedTail && r.gen == s.replayGen {
+		s.unknown = false
+	}
+	s.wmMu.Unlock()
+	<-s.replaySem
+}
+
+// ReplayNext returns the replay cursor: every commit in the log with a
+// smaller GSN has been applied.
+func (s *MapService) ReplayNext() uint64 {
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	return s.replayNext
+}
+
+// NeedsReplay reports whether a commit of unknown outcome is waiting for a
+// replay of the log.
+func (s *MapService) NeedsReplay() bool {
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	return s.unknown
+}
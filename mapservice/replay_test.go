@@ -0,0 +1,57 @@
+package mapservice
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReplayCursor(t *testing.T) {
+	ctx := context.Background()
+	s := NewMapService()
+	s.ApplyCommit(10, []CommitEntry{put("a", 9)})
+
+	r, err := s.BeginReplay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Advance(20)
+	r.Advance(5) // never moves back
+	if next := r.Next(); next != 20 {
+		t.Fatalf("Next = %d, want 20", next)
+	}
+
+	// a commit of unknown outcome aborted during the replay moves the cursor
+	// back, and that replay does not count for it
+	c := s.BeginCommit()
+	c.AbortUnknown()
+	if next := s.ReplayNext(); next != 11 {
+		t.Fatalf("ReplayNext = %d after the abort, want the commit's lower bound 11", next)
+	}
+	r.Done(true)
+	if !s.NeedsReplay() {
+		t.Fatal("replay started before the abort cleared it")
+	}
+
+	// only one replay runs at a time
+	r, err = s.BeginReplay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.BeginReplay(cctx); err == nil {
+		t.Fatal("second replay started while one is running")
+	}
+	r.Done(false)
+	if !s.NeedsReplay() {
+		t.Fatal("replay that did not reach the tail cleared NeedsReplay")
+	}
+	r, err = s.BeginReplay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(true)
+	if s.NeedsReplay() {
+		t.Fatal("replay that reached the tail left NeedsReplay set")
+	}
+}
@@ -0,0 +1,30 @@
+package mapservice
+
+// ClientSession is the last request committed for a client ID: its sequence
+// number and the commit GSN it produced.
+type ClientSession struct {
+	Seq       uint64
+	CommitGSN uint64
+}
+
+// RecordRequest notes that request seq of client committed at commitGSN.
+// Older sequence numbers are ignored, so replaying the log again is
+// harmless.
+//
+// Only the last request per client is kept; the table is never pruned.
+func (s *MapService) RecordRequest(clientID string, seq, commitGSN uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.sessions[clientID]; ok && cur.Seq >= seq {
+		return
+	}
+	s.sessions[clientID] = ClientSession{Seq: seq, CommitGSN: commitGSN}
+}
+
+// LastRequest returns the last request recorded for clientID.
+func (s *MapService) LastRequest(clientID string) (ClientSession, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cs, ok := s.sessions[clientID]
+	return cs, ok
+}
@@ -0,0 +1,118 @@
+package mapservice
+
+import (
+	"context"
+	"math"
+)
+
+// PendingCommit tracks one write request from before its first append
+// (DATA or COMMIT) until ApplyCommit, so that StableGSN never moves past a
+// record that has been (or is being) appended but is not visible yet.
+type PendingCommit struct {
+	s  *MapService
+	id uint64
+	// lb is a lower bound of the commit's GSN: the append starts after every
+	// commit applied so far, so its GSN is larger than maxCommitGSN was.
+	lb uint64
+}
+
+// BeginCommit must be called before appending any record of a commit that
+// will be applied with PendingCommit.Apply.
+func (s *MapService) BeginCommit() *PendingCommit {
+	s.mu.RLock()
+	lb := s.maxCommitGSN + 1
+	s.mu.RUnlock()
+
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	s.nextPendingID++
+	c := &PendingCommit{s: s, id: s.nextPendingID, lb: lb}
+	s.pending[c.id] = c.lb
+	return c
+}
+
+// Apply applies the commit and stops tracking it.
+func (c *PendingCommit) Apply(commitGSN uint64, entries []CommitEntry) {
+	c.s.ApplyCommit(commitGSN, entries)
+	c.done()
+}
+
+// Abort stops tracking a commit whose append failed.
+func (c *PendingCommit) Abort() { c.done() }
+
+// AbortUnknown stops tracking a commit whose append failed in a way that
+// may still have reached the log. The replay cursor moves back to its GSN
+// lower bound, and NeedsReplay reports true until a replay that started
+// after the abort has reached the tail of the log.
+func (c *PendingCommit) AbortUnknown() {
+	s := c.s
+	s.wmMu.Lock()
+	if c.lb < s.replayNext {
+		s.replayNext = c.lb
+	}
+	s.unknown = true
+	s.replayGen++
+	s.wmMu.Unlock()
+	c.done()
+}
+
+func (c *PendingCommit) done() {
+	s := c.s
+	s.wmMu.Lock()
+	delete(s.pending, c.id)
+	s.wmMu.Unlock()
+	s.notifyWatermark()
+}
+
+// notifyWatermark wakes WaitStable callers and releases the commits that
+// became stable to watchers. It must not be called with s.mu held:
+// stableLocked takes s.mu while holding wmMu.
+func (s *MapService) notifyWatermark() {
+	s.wmMu.Lock()
+	close(s.wmChanged)
+	s.wmChanged = make(chan struct{})
+	s.wmMu.Unlock()
+
+	s.releaseChanges()
+}
+
+// StableGSN returns a GSN such that every commit with a GSN <= it has been
+// applied. It lags MaxCommitGSN while commits are in flight.
+func (s *MapService) StableGSN() uint64 {
+	s.wmMu.Lock()
+	defer s.wmMu.Unlock()
+	return s.stableLocked()
+}
+
+// caller holds wmMu
+func (s *MapService) stableLocked() uint64 {
+	stable := uint64(math.MaxUint64)
+	for _, lb := range s.pending {
+		if lb-1 < stable {
+			stable = lb - 1
+		}
+	}
+	if max := s.MaxCommitGSN(); max < stable {
+		stable = max
+	}
+	return stable
+}
+
+// WaitStable blocks until StableGSN() >= gsn or ctx is done.
+func (s *MapService) WaitStable(ctx context.Context, gsn uint64) error {
+	for {
+		s.wmMu.Lock()
+		if s.stableLocked() >= gsn {
+			s.wmMu.Unlock()
+			return nil
+		}
+		changed := s.wmChanged
+		s.wmMu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
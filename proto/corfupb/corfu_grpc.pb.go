@@ -0,0 +1,469 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.0
+// source: proto/corfu.proto
+
+package corfupb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Sequencer_Next_FullMethodName = "/corfu.Sequencer/Next"
+	Sequencer_Tail_FullMethodName = "/corfu.Sequencer/Tail"
+)
+
+// SequencerClient is the client API for Sequencer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Sequencer hands out log positions (GSNs).
+type SequencerClient interface {
+	// Next reserves count consecutive GSNs and returns the first one.
+	Next(ctx context.Context, in *NextRequest, opts ...grpc.CallOption) (*NextResponse, error)
+	// Tail returns the last GSN handed out.
+	Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (*PositionResponse, error)
+}
+
+type sequencerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSequencerClient(cc grpc.ClientConnInterface) SequencerClient {
+	return &sequencerClient{cc}
+}
+
+func (c *sequencerClient) Next(ctx context.Context, in *NextRequest, opts ...grpc.CallOption) (*NextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NextResponse)
+	err := c.cc.Invoke(ctx, Sequencer_Next_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (*PositionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PositionResponse)
+	err := c.cc.Invoke(ctx, Sequencer_Tail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SequencerServer is the server API for Sequencer service.
+// All implementations must embed UnimplementedSequencerServer
+// for forward compatibility.
+//
+// Sequencer hands out log positions (GSNs).
+type SequencerServer interface {
+	// Next reserves count consecutive GSNs and returns the first one.
+	Next(context.Context, *NextRequest) (*NextResponse, error)
+	// Tail returns the last GSN handed out.
+	Tail(context.Context, *TailRequest) (*PositionResponse, error)
+	mustEmbedUnimplementedSequencerServer()
+}
+
+// UnimplementedSequencerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSequencerServer struct{}
+
+func (UnimplementedSequencerServer) Next(context.Context, *NextRequest) (*NextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Next not implemented")
+}
+func (UnimplementedSequencerServer) Tail(context.Context, *TailRequest) (*PositionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Tail not implemented")
+}
+func (UnimplementedSequencerServer) mustEmbedUnimplementedSequencerServer() {}
+func (UnimplementedSequencerServer) testEmbeddedByValue()                   {}
+
+// UnsafeSequencerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SequencerServer will
+// result in compilation errors.
+type UnsafeSequencerServer interface {
+	mustEmbedUnimplementedSequencerServer()
+}
+
+func RegisterSequencerServer(s grpc.ServiceRegistrar, srv SequencerServer) {
+	// If the following call pancis, it indicates UnimplementedSequencerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Sequencer_ServiceDesc, srv)
+}
+
+func _Sequencer_Next_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).Next(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sequencer_Next_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).Next(ctx, req.(*NextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_Tail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).Tail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sequencer_Tail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).Tail(ctx, req.(*TailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sequencer_ServiceDesc is the grpc.ServiceDesc for Sequencer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sequencer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "corfu.Sequencer",
+	HandlerType: (*SequencerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Next",
+			Handler:    _Sequencer_Next_Handler,
+		},
+		{
+			MethodName: "Tail",
+			Handler:    _Sequencer_Tail_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/corfu.proto",
+}
+
+const (
+	StorageUnit_Write_FullMethodName = "/corfu.StorageUnit/Write"
+	StorageUnit_Read_FullMethodName  = "/corfu.StorageUnit/Read"
+	StorageUnit_Fill_FullMethodName  = "/corfu.StorageUnit/Fill"
+	StorageUnit_Trim_FullMethodName  = "/corfu.StorageUnit/Trim"
+	StorageUnit_Head_FullMethodName  = "/corfu.StorageUnit/Head"
+	StorageUnit_Tail_FullMethodName  = "/corfu.StorageUnit/Tail"
+)
+
+// StorageUnitClient is the client API for StorageUnit service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// StorageUnit stores the log positions of one stripe. Every position is
+// write-once: it is either written with data or filled with junk.
+type StorageUnitClient interface {
+	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*ReadResponse, error)
+	Fill(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*FillResponse, error)
+	Trim(ctx context.Context, in *TrimRequest, opts ...grpc.CallOption) (*TrimResponse, error)
+	// Head returns the lowest untrimmed GSN.
+	Head(ctx context.Context, in *HeadRequest, opts ...grpc.CallOption) (*PositionResponse, error)
+	// Tail returns the highest written or filled GSN, 0 if none.
+	Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (*PositionResponse, error)
+}
+
+type storageUnitClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStorageUnitClient(cc grpc.ClientConnInterface) StorageUnitClient {
+	return &storageUnitClient{cc}
+}
+
+func (c *storageUnitClient) Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Write_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageUnitClient) Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*ReadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Read_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageUnitClient) Fill(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*FillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FillResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Fill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageUnitClient) Trim(ctx context.Context, in *TrimRequest, opts ...grpc.CallOption) (*TrimResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TrimResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Trim_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageUnitClient) Head(ctx context.Context, in *HeadRequest, opts ...grpc.CallOption) (*PositionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PositionResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Head_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageUnitClient) Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (*PositionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PositionResponse)
+	err := c.cc.Invoke(ctx, StorageUnit_Tail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StorageUnitServer is the server API for StorageUnit service.
+// All implementations must embed UnimplementedStorageUnitServer
+// for forward compatibility.
+//
+// StorageUnit stores the log positions of one stripe. Every position is
+// write-once: it is either written with data or filled with junk.
+type StorageUnitServer interface {
+	Write(context.Context, *WriteRequest) (*WriteResponse, error)
+	Read(context.Context, *ReadRequest) (*ReadResponse, error)
+	Fill(context.Context, *FillRequest) (*FillResponse, error)
+	Trim(context.Context, *TrimRequest) (*TrimResponse, error)
+	// Head returns the lowest untrimmed GSN.
+	Head(context.Context, *HeadRequest) (*PositionResponse, error)
+	// Tail returns the highest written or filled GSN, 0 if none.
+	Tail(context.Context, *TailRequest) (*PositionResponse, error)
+	mustEmbedUnimplementedStorageUnitServer()
+}
+
+// UnimplementedStorageUnitServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStorageUnitServer struct{}
+
+func (UnimplementedStorageUnitServer) Write(context.Context, *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedStorageUnitServer) Read(context.Context, *ReadRequest) (*ReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedStorageUnitServer) Fill(context.Context, *FillRequest) (*FillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fill not implemented")
+}
+func (UnimplementedStorageUnitServer) Trim(context.Context, *TrimRequest) (*TrimResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Trim not implemented")
+}
+func (UnimplementedStorageUnitServer) Head(context.Context, *HeadRequest) (*PositionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Head not implemented")
+}
+func (UnimplementedStorageUnitServer) Tail(context.Context, *TailRequest) (*PositionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Tail not implemented")
+}
+func (UnimplementedStorageUnitServer) mustEmbedUnimplementedStorageUnitServer() {}
+func (UnimplementedStorageUnitServer) testEmbeddedByValue()                     {}
+
+// UnsafeStorageUnitServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StorageUnitServer will
+// result in compilation errors.
+type UnsafeStorageUnitServer interface {
+	mustEmbedUnimplementedStorageUnitServer()
+}
+
+func RegisterStorageUnitServer(s grpc.ServiceRegistrar, srv StorageUnitServer) {
+	// If the following call pancis, it indicates UnimplementedStorageUnitServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StorageUnit_ServiceDesc, srv)
+}
+
+func _StorageUnit_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Write_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Write(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageUnit_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Read_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Read(ctx, req.(*ReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageUnit_Fill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Fill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Fill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Fill(ctx, req.(*FillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageUnit_Trim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrimRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Trim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Trim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Trim(ctx, req.(*TrimRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageUnit_Head_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Head(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Head_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Head(ctx, req.(*HeadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageUnit_Tail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageUnitServer).Tail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageUnit_Tail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageUnitServer).Tail(ctx, req.(*TailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StorageUnit_ServiceDesc is the grpc.ServiceDesc for StorageUnit service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StorageUnit_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "corfu.StorageUnit",
+	HandlerType: (*StorageUnitServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Write",
+			Handler:    _StorageUnit_Write_Handler,
+		},
+		{
+			MethodName: "Read",
+			Handler:    _StorageUnit_Read_Handler,
+		},
+		{
+			MethodName: "Fill",
+			Handler:    _StorageUnit_Fill_Handler,
+		},
+		{
+			MethodName: "Trim",
+			Handler:    _StorageUnit_Trim_Handler,
+		},
+		{
+			MethodName: "Head",
+			Handler:    _StorageUnit_Head_Handler,
+		},
+		{
+			MethodName: "Tail",
+			Handler:    _StorageUnit_Tail_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/corfu.proto",
+}
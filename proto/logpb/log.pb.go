@@ -0,0 +1,1077 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.0
+// source: proto/log.proto
+
+package logpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RecordRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gsn           uint64                 `protobuf:"varint,1,opt,name=gsn,proto3" json:"gsn,omitempty"`
+	ShardId       uint32                 `protobuf:"varint,2,opt,name=shard_id,json=shardId,proto3" json:"shard_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordRef) Reset() {
+	*x = RecordRef{}
+	mi := &file_proto_log_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordRef) ProtoMessage() {}
+
+func (x *RecordRef) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordRef.ProtoReflect.Descriptor instead.
+func (*RecordRef) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecordRef) GetGsn() uint64 {
+	if x != nil {
+		return x.Gsn
+	}
+	return 0
+}
+
+func (x *RecordRef) GetShardId() uint32 {
+	if x != nil {
+		return x.ShardId
+	}
+	return 0
+}
+
+type DataRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TxnId         string                 `protobuf:"bytes,3,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	Token         string                 `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataRecord) Reset() {
+	*x = DataRecord{}
+	mi := &file_proto_log_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataRecord) ProtoMessage() {}
+
+func (x *DataRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataRecord.ProtoReflect.Descriptor instead.
+func (*DataRecord) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DataRecord) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *DataRecord) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *DataRecord) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *DataRecord) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type CommitEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Ref           *RecordRef             `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	Tombstone     bool                   `protobuf:"varint,3,opt,name=tombstone,proto3" json:"tombstone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitEntry) Reset() {
+	*x = CommitEntry{}
+	mi := &file_proto_log_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitEntry) ProtoMessage() {}
+
+func (x *CommitEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitEntry.ProtoReflect.Descriptor instead.
+func (*CommitEntry) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CommitEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CommitEntry) GetRef() *RecordRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *CommitEntry) GetTombstone() bool {
+	if x != nil {
+		return x.Tombstone
+	}
+	return false
+}
+
+type CommitRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*CommitEntry         `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	TxnId         string                 `protobuf:"bytes,2,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	ClientId      string                 `protobuf:"bytes,4,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Seq           uint64                 `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRecord) Reset() {
+	*x = CommitRecord{}
+	mi := &file_proto_log_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRecord) ProtoMessage() {}
+
+func (x *CommitRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRecord.ProtoReflect.Descriptor instead.
+func (*CommitRecord) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CommitRecord) GetEntries() []*CommitEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *CommitRecord) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *CommitRecord) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CommitRecord) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *CommitRecord) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type Record struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Record_Data
+	//	*Record_Commit
+	Body          isRecord_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_proto_log_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Record) GetBody() isRecord_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Record) GetData() *DataRecord {
+	if x != nil {
+		if x, ok := x.Body.(*Record_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *Record) GetCommit() *CommitRecord {
+	if x != nil {
+		if x, ok := x.Body.(*Record_Commit); ok {
+			return x.Commit
+		}
+	}
+	return nil
+}
+
+type isRecord_Body interface {
+	isRecord_Body()
+}
+
+type Record_Data struct {
+	Data *DataRecord `protobuf:"bytes,1,opt,name=data,proto3,oneof"`
+}
+
+type Record_Commit struct {
+	Commit *CommitRecord `protobuf:"bytes,2,opt,name=commit,proto3,oneof"`
+}
+
+func (*Record_Data) isRecord_Body() {}
+
+func (*Record_Commit) isRecord_Body() {}
+
+type AppendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*Record              `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_proto_log_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AppendRequest) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// AppendResponse has a ref per record. If the backend appended only some of
+// them, errors lists the others and their refs are unset.
+type AppendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refs          []*RecordRef           `protobuf:"bytes,1,rep,name=refs,proto3" json:"refs,omitempty"`
+	Errors        []*AppendError         `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendResponse) Reset() {
+	*x = AppendResponse{}
+	mi := &file_proto_log_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendResponse) ProtoMessage() {}
+
+func (x *AppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendResponse.ProtoReflect.Descriptor instead.
+func (*AppendResponse) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AppendResponse) GetRefs() []*RecordRef {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+func (x *AppendResponse) GetErrors() []*AppendError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type AppendError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// code and message of the record's gRPC status
+	Code          uint32 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendError) Reset() {
+	*x = AppendError{}
+	mi := &file_proto_log_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendError) ProtoMessage() {}
+
+func (x *AppendError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendError.ProtoReflect.Descriptor instead.
+func (*AppendError) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AppendError) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *AppendError) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *AppendError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *RecordRef             `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_proto_log_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReadRequest) GetRef() *RecordRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+type ReadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        *DataRecord            `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadResponse) Reset() {
+	*x = ReadResponse{}
+	mi := &file_proto_log_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadResponse) ProtoMessage() {}
+
+func (x *ReadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadResponse.ProtoReflect.Descriptor instead.
+func (*ReadResponse) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReadResponse) GetRecord() *DataRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type ReadRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromGsn       uint64                 `protobuf:"varint,1,opt,name=from_gsn,json=fromGsn,proto3" json:"from_gsn,omitempty"`
+	ToGsn         uint64                 `protobuf:"varint,2,opt,name=to_gsn,json=toGsn,proto3" json:"to_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRangeRequest) Reset() {
+	*x = ReadRangeRequest{}
+	mi := &file_proto_log_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRangeRequest) ProtoMessage() {}
+
+func (x *ReadRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRangeRequest.ProtoReflect.Descriptor instead.
+func (*ReadRangeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReadRangeRequest) GetFromGsn() uint64 {
+	if x != nil {
+		return x.FromGsn
+	}
+	return 0
+}
+
+func (x *ReadRangeRequest) GetToGsn() uint64 {
+	if x != nil {
+		return x.ToGsn
+	}
+	return 0
+}
+
+type CommitAt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gsn           uint64                 `protobuf:"varint,1,opt,name=gsn,proto3" json:"gsn,omitempty"`
+	Record        *CommitRecord          `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitAt) Reset() {
+	*x = CommitAt{}
+	mi := &file_proto_log_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitAt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitAt) ProtoMessage() {}
+
+func (x *CommitAt) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitAt.ProtoReflect.Descriptor instead.
+func (*CommitAt) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CommitAt) GetGsn() uint64 {
+	if x != nil {
+		return x.Gsn
+	}
+	return 0
+}
+
+func (x *CommitAt) GetRecord() *CommitRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromGsn       uint64                 `protobuf:"varint,1,opt,name=from_gsn,json=fromGsn,proto3" json:"from_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_proto_log_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SubscribeRequest) GetFromGsn() uint64 {
+	if x != nil {
+		return x.FromGsn
+	}
+	return 0
+}
+
+type TailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailRequest) Reset() {
+	*x = TailRequest{}
+	mi := &file_proto_log_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailRequest) ProtoMessage() {}
+
+func (x *TailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailRequest.ProtoReflect.Descriptor instead.
+func (*TailRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{13}
+}
+
+type TailResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Head          uint64                 `protobuf:"varint,1,opt,name=head,proto3" json:"head,omitempty"`
+	Tail          uint64                 `protobuf:"varint,2,opt,name=tail,proto3" json:"tail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailResponse) Reset() {
+	*x = TailResponse{}
+	mi := &file_proto_log_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailResponse) ProtoMessage() {}
+
+func (x *TailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailResponse.ProtoReflect.Descriptor instead.
+func (*TailResponse) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TailResponse) GetHead() uint64 {
+	if x != nil {
+		return x.Head
+	}
+	return 0
+}
+
+func (x *TailResponse) GetTail() uint64 {
+	if x != nil {
+		return x.Tail
+	}
+	return 0
+}
+
+type TrimRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UpTo          uint64                 `protobuf:"varint,1,opt,name=up_to,json=upTo,proto3" json:"up_to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrimRequest) Reset() {
+	*x = TrimRequest{}
+	mi := &file_proto_log_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrimRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrimRequest) ProtoMessage() {}
+
+func (x *TrimRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrimRequest.ProtoReflect.Descriptor instead.
+func (*TrimRequest) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TrimRequest) GetUpTo() uint64 {
+	if x != nil {
+		return x.UpTo
+	}
+	return 0
+}
+
+type TrimResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrimResponse) Reset() {
+	*x = TrimResponse{}
+	mi := &file_proto_log_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrimResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrimResponse) ProtoMessage() {}
+
+func (x *TrimResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_log_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrimResponse.ProtoReflect.Descriptor instead.
+func (*TrimResponse) Descriptor() ([]byte, []int) {
+	return file_proto_log_proto_rawDescGZIP(), []int{16}
+}
+
+var File_proto_log_proto protoreflect.FileDescriptor
+
+const file_proto_log_proto_rawDesc = "" +
+	"\n" +
+	"\x0fproto/log.proto\x12\x03log\"8\n" +
+	"\tRecordRef\x12\x10\n" +
+	"\x03gsn\x18\x01 \x01(\x04R\x03gsn\x12\x19\n" +
+	"\bshard_id\x18\x02 \x01(\rR\ashardId\"a\n" +
+	"\n" +
+	"DataRecord\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x15\n" +
+	"\x06txn_id\x18\x03 \x01(\tR\x05txnId\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\tR\x05token\"_\n" +
+	"\vCommitEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12 \n" +
+	"\x03ref\x18\x02 \x01(\v2\x0e.log.RecordRefR\x03ref\x12\x1c\n" +
+	"\ttombstone\x18\x03 \x01(\bR\ttombstone\"\x96\x01\n" +
+	"\fCommitRecord\x12*\n" +
+	"\aentries\x18\x01 \x03(\v2\x10.log.CommitEntryR\aentries\x12\x15\n" +
+	"\x06txn_id\x18\x02 \x01(\tR\x05txnId\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12\x1b\n" +
+	"\tclient_id\x18\x04 \x01(\tR\bclientId\x12\x10\n" +
+	"\x03seq\x18\x05 \x01(\x04R\x03seq\"d\n" +
+	"\x06Record\x12%\n" +
+	"\x04data\x18\x01 \x01(\v2\x0f.log.DataRecordH\x00R\x04data\x12+\n" +
+	"\x06commit\x18\x02 \x01(\v2\x11.log.CommitRecordH\x00R\x06commitB\x06\n" +
+	"\x04body\"6\n" +
+	"\rAppendRequest\x12%\n" +
+	"\arecords\x18\x01 \x03(\v2\v.log.RecordR\arecords\"^\n" +
+	"\x0eAppendResponse\x12\"\n" +
+	"\x04refs\x18\x01 \x03(\v2\x0e.log.RecordRefR\x04refs\x12(\n" +
+	"\x06errors\x18\x02 \x03(\v2\x10.log.AppendErrorR\x06errors\"Q\n" +
+	"\vAppendError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\rR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"/\n" +
+	"\vReadRequest\x12 \n" +
+	"\x03ref\x18\x01 \x01(\v2\x0e.log.RecordRefR\x03ref\"7\n" +
+	"\fReadResponse\x12'\n" +
+	"\x06record\x18\x01 \x01(\v2\x0f.log.DataRecordR\x06record\"D\n" +
+	"\x10ReadRangeRequest\x12\x19\n" +
+	"\bfrom_gsn\x18\x01 \x01(\x04R\afromGsn\x12\x15\n" +
+	"\x06to_gsn\x18\x02 \x01(\x04R\x05toGsn\"G\n" +
+	"\bCommitAt\x12\x10\n" +
+	"\x03gsn\x18\x01 \x01(\x04R\x03gsn\x12)\n" +
+	"\x06record\x18\x02 \x01(\v2\x11.log.CommitRecordR\x06record\"-\n" +
+	"\x10SubscribeRequest\x12\x19\n" +
+	"\bfrom_gsn\x18\x01 \x01(\x04R\afromGsn\"\r\n" +
+	"\vTailRequest\"6\n" +
+	"\fTailResponse\x12\x12\n" +
+	"\x04head\x18\x01 \x01(\x04R\x04head\x12\x12\n" +
+	"\x04tail\x18\x02 \x01(\x04R\x04tail\"\"\n" +
+	"\vTrimRequest\x12\x13\n" +
+	"\x05up_to\x18\x01 \x01(\x04R\x04upTo\"\x0e\n" +
+	"\fTrimResponse2\xa9\x02\n" +
+	"\x03Log\x121\n" +
+	"\x06Append\x12\x12.log.AppendRequest\x1a\x13.log.AppendResponse\x12+\n" +
+	"\x04Read\x12\x10.log.ReadRequest\x1a\x11.log.ReadResponse\x123\n" +
+	"\tReadRange\x12\x15.log.ReadRangeRequest\x1a\r.log.CommitAt0\x01\x123\n" +
+	"\tSubscribe\x12\x15.log.SubscribeRequest\x1a\r.log.CommitAt0\x01\x12+\n" +
+	"\x04Tail\x12\x10.log.TailRequest\x1a\x11.log.TailResponse\x12+\n" +
+	"\x04Trim\x12\x10.log.TrimRequest\x1a\x11.log.TrimResponseB\x0fZ\r./proto/logpbb\x06proto3"
+
+var (
+	file_proto_log_proto_rawDescOnce sync.Once
+	file_proto_log_proto_rawDescData []byte
+)
+
+func file_proto_log_proto_rawDescGZIP() []byte {
+	file_proto_log_proto_rawDescOnce.Do(func() {
+		file_proto_log_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_log_proto_rawDesc), len(file_proto_log_proto_rawDesc)))
+	})
+	return file_proto_log_proto_rawDescData
+}
+
+var file_proto_log_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_proto_log_proto_goTypes = []any{
+	(*RecordRef)(nil),        // 0: log.RecordRef
+	(*DataRecord)(nil),       // 1: log.DataRecord
+	(*CommitEntry)(nil),      // 2: log.CommitEntry
+	(*CommitRecord)(nil),     // 3: log.CommitRecord
+	(*Record)(nil),           // 4: log.Record
+	(*AppendRequest)(nil),    // 5: log.AppendRequest
+	(*AppendResponse)(nil),   // 6: log.AppendResponse
+	(*AppendError)(nil),      // 7: log.AppendError
+	(*ReadRequest)(nil),      // 8: log.ReadRequest
+	(*ReadResponse)(nil),     // 9: log.ReadResponse
+	(*ReadRangeRequest)(nil), // 10: log.ReadRangeRequest
+	(*CommitAt)(nil),         // 11: log.CommitAt
+	(*SubscribeRequest)(nil), // 12: log.SubscribeRequest
+	(*TailRequest)(nil),      // 13: log.TailRequest
+	(*TailResponse)(nil),     // 14: log.TailResponse
+	(*TrimRequest)(nil),      // 15: log.TrimRequest
+	(*TrimResponse)(nil),     // 16: log.TrimResponse
+}
+var file_proto_log_proto_depIdxs = []int32{
+	0,  // 0: log.CommitEntry.ref:type_name -> log.RecordRef
+	2,  // 1: log.CommitRecord.entries:type_name -> log.CommitEntry
+	1,  // 2: log.Record.data:type_name -> log.DataRecord
+	3,  // 3: log.Record.commit:type_name -> log.CommitRecord
+	4,  // 4: log.AppendRequest.records:type_name -> log.Record
+	0,  // 5: log.AppendResponse.refs:type_name -> log.RecordRef
+	7,  // 6: log.AppendResponse.errors:type_name -> log.AppendError
+	0,  // 7: log.ReadRequest.ref:type_name -> log.RecordRef
+	1,  // 8: log.ReadResponse.record:type_name -> log.DataRecord
+	3,  // 9: log.CommitAt.record:type_name -> log.CommitRecord
+	5,  // 10: log.Log.Append:input_type -> log.AppendRequest
+	8,  // 11: log.Log.Read:input_type -> log.ReadRequest
+	10, // 12: log.Log.ReadRange:input_type -> log.ReadRangeRequest
+	12, // 13: log.Log.Subscribe:input_type -> log.SubscribeRequest
+	13, // 14: log.Log.Tail:input_type -> log.TailRequest
+	15, // 15: log.Log.Trim:input_type -> log.TrimRequest
+	6,  // 16: log.Log.Append:output_type -> log.AppendResponse
+	9,  // 17: log.Log.Read:output_type -> log.ReadResponse
+	11, // 18: log.Log.ReadRange:output_type -> log.CommitAt
+	11, // 19: log.Log.Subscribe:output_type -> log.CommitAt
+	14, // 20: log.Log.Tail:output_type -> log.TailResponse
+	16, // 21: log.Log.Trim:output_type -> log.TrimResponse
+	16, // [16:22] is the sub-list for method output_type
+	10, // [10:16] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_proto_log_proto_init() }
+func file_proto_log_proto_init() {
+	if File_proto_log_proto != nil {
+		return
+	}
+	file_proto_log_proto_msgTypes[4].OneofWrappers = []any{
+		(*Record_Data)(nil),
+		(*Record_Commit)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_log_proto_rawDesc), len(file_proto_log_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_log_proto_goTypes,
+		DependencyIndexes: file_proto_log_proto_depIdxs,
+		MessageInfos:      file_proto_log_proto_msgTypes,
+	}.Build()
+	File_proto_log_proto = out.File
+	file_proto_log_proto_goTypes = nil
+	file_proto_log_proto_depIdxs = nil
+}
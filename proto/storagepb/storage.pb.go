@@ -21,17 +21,72 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-type KV struct {
+// RecordRef locates a DATA record in the shared log.
+type RecordRef struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Gsn           uint64                 `protobuf:"varint,1,opt,name=gsn,proto3" json:"gsn,omitempty"`
+	ShardId       uint32                 `protobuf:"varint,2,opt,name=shard_id,json=shardId,proto3" json:"shard_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordRef) Reset() {
+	*x = RecordRef{}
+	mi := &file_proto_storage_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordRef) ProtoMessage() {}
+
+func (x *RecordRef) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordRef.ProtoReflect.Descriptor instead.
+func (*RecordRef) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecordRef) GetGsn() uint64 {
+	if x != nil {
+		return x.Gsn
+	}
+	return 0
+}
+
+func (x *RecordRef) GetShardId() uint32 {
+	if x != nil {
+		return x.ShardId
+	}
+	return 0
+}
+
+type KV struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// delete removes the key instead of writing value.
+	Delete        bool `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *KV) Reset() {
 	*x = KV{}
-	mi := &file_proto_storage_proto_msgTypes[0]
+	mi := &file_proto_storage_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -43,7 +98,7 @@ func (x *KV) String() string {
 func (*KV) ProtoMessage() {}
 
 func (x *KV) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[0]
+	mi := &file_proto_storage_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,7 +111,7 @@ func (x *KV) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KV.ProtoReflect.Descriptor instead.
 func (*KV) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{0}
+	return file_proto_storage_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *KV) GetKey() string {
@@ -73,16 +128,30 @@ func (x *KV) GetValue() []byte {
 	return nil
 }
 
+func (x *KV) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+// A MultiPutRequest with a client_id is applied at most once. seq starts at
+// 1 and must grow with every new request of that client; resending the last committed seq
+// returns that commit's outcome instead of applying it again, and an older
+// seq fails with FAILED_PRECONDITION. Use one client_id per client instance
+// and send its requests one at a time.
 type MultiPutRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Kvs           []*KV                  `protobuf:"bytes,1,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MultiPutRequest) Reset() {
 	*x = MultiPutRequest{}
-	mi := &file_proto_storage_proto_msgTypes[1]
+	mi := &file_proto_storage_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -94,7 +163,7 @@ func (x *MultiPutRequest) String() string {
 func (*MultiPutRequest) ProtoMessage() {}
 
 func (x *MultiPutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[1]
+	mi := &file_proto_storage_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -107,7 +176,7 @@ func (x *MultiPutRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MultiPutRequest.ProtoReflect.Descriptor instead.
 func (*MultiPutRequest) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{1}
+	return file_proto_storage_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *MultiPutRequest) GetKvs() []*KV {
@@ -117,16 +186,38 @@ func (x *MultiPutRequest) GetKvs() []*KV {
 	return nil
 }
 
+func (x *MultiPutRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *MultiPutRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type MultiPutResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ok    bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// commit_gsn identifies the state this write produced; pass it as
+	// MultiGetRequest.snapshot_gsn to read exactly that state later, or as
+	// min_commit_gsn to read your own write on another server.
+	CommitGsn uint64 `protobuf:"varint,2,opt,name=commit_gsn,json=commitGsn,proto3" json:"commit_gsn,omitempty"`
+	// refs[key] is the DATA record written for key; deleted keys have none.
+	// A deduplicated retry whose commit has been trimmed from the log gets
+	// commit_gsn only.
+	Refs          map[string]*RecordRef `protobuf:"bytes,3,rep,name=refs,proto3" json:"refs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MultiPutResponse) Reset() {
 	*x = MultiPutResponse{}
-	mi := &file_proto_storage_proto_msgTypes[2]
+	mi := &file_proto_storage_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -138,7 +229,7 @@ func (x *MultiPutResponse) String() string {
 func (*MultiPutResponse) ProtoMessage() {}
 
 func (x *MultiPutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[2]
+	mi := &file_proto_storage_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -151,7 +242,7 @@ func (x *MultiPutResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MultiPutResponse.ProtoReflect.Descriptor instead.
 func (*MultiPutResponse) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{2}
+	return file_proto_storage_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *MultiPutResponse) GetOk() bool {
@@ -161,16 +252,126 @@ func (x *MultiPutResponse) GetOk() bool {
 	return false
 }
 
-type MultiGetRequest struct {
+func (x *MultiPutResponse) GetCommitGsn() uint64 {
+	if x != nil {
+		return x.CommitGsn
+	}
+	return 0
+}
+
+func (x *MultiPutResponse) GetRefs() map[string]*RecordRef {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+type MultiDeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *MultiDeleteRequest) Reset() {
+	*x = MultiDeleteRequest{}
+	mi := &file_proto_storage_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDeleteRequest) ProtoMessage() {}
+
+func (x *MultiDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDeleteRequest.ProtoReflect.Descriptor instead.
+func (*MultiDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MultiDeleteRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type MultiDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiDeleteResponse) Reset() {
+	*x = MultiDeleteResponse{}
+	mi := &file_proto_storage_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDeleteResponse) ProtoMessage() {}
+
+func (x *MultiDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDeleteResponse.ProtoReflect.Descriptor instead.
+func (*MultiDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MultiDeleteResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type MultiGetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Keys  []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	// snapshot_gsn, if non-zero, returns the values as of that commit GSN
+	// instead of the latest ones.
+	SnapshotGsn uint64 `protobuf:"varint,2,opt,name=snapshot_gsn,json=snapshotGsn,proto3" json:"snapshot_gsn,omitempty"`
+	// min_commit_gsn, if non-zero, waits until every commit up to it, from
+	// any server sharing the log, has been applied on this server before
+	// reading. The wait is bounded by the call's deadline and by the server's
+	// max-read-wait; either fails the call with DEADLINE_EXCEEDED.
+	MinCommitGsn  uint64 `protobuf:"varint,3,opt,name=min_commit_gsn,json=minCommitGsn,proto3" json:"min_commit_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
 func (x *MultiGetRequest) Reset() {
 	*x = MultiGetRequest{}
-	mi := &file_proto_storage_proto_msgTypes[3]
+	mi := &file_proto_storage_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -182,7 +383,7 @@ func (x *MultiGetRequest) String() string {
 func (*MultiGetRequest) ProtoMessage() {}
 
 func (x *MultiGetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[3]
+	mi := &file_proto_storage_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -195,7 +396,7 @@ func (x *MultiGetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MultiGetRequest.ProtoReflect.Descriptor instead.
 func (*MultiGetRequest) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{3}
+	return file_proto_storage_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *MultiGetRequest) GetKeys() []string {
@@ -205,16 +406,33 @@ func (x *MultiGetRequest) GetKeys() []string {
 	return nil
 }
 
+func (x *MultiGetRequest) GetSnapshotGsn() uint64 {
+	if x != nil {
+		return x.SnapshotGsn
+	}
+	return 0
+}
+
+func (x *MultiGetRequest) GetMinCommitGsn() uint64 {
+	if x != nil {
+		return x.MinCommitGsn
+	}
+	return 0
+}
+
 type MultiGetResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Values        map[string][]byte      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Values map[string][]byte      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// commit_gsns[key] is the commit GSN that last wrote the returned value;
+	// pass it back in TxnRequest.reads to commit conditionally on it.
+	CommitGsns    map[string]uint64 `protobuf:"bytes,2,rep,name=commit_gsns,json=commitGsns,proto3" json:"commit_gsns,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MultiGetResponse) Reset() {
 	*x = MultiGetResponse{}
-	mi := &file_proto_storage_proto_msgTypes[4]
+	mi := &file_proto_storage_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -226,7 +444,7 @@ func (x *MultiGetResponse) String() string {
 func (*MultiGetResponse) ProtoMessage() {}
 
 func (x *MultiGetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[4]
+	mi := &file_proto_storage_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -239,7 +457,7 @@ func (x *MultiGetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MultiGetResponse.ProtoReflect.Descriptor instead.
 func (*MultiGetResponse) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{4}
+	return file_proto_storage_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *MultiGetResponse) GetValues() map[string][]byte {
@@ -249,28 +467,628 @@ func (x *MultiGetResponse) GetValues() map[string][]byte {
 	return nil
 }
 
+func (x *MultiGetResponse) GetCommitGsns() map[string]uint64 {
+	if x != nil {
+		return x.CommitGsns
+	}
+	return nil
+}
+
+// ScanRequest selects keys in [start_key, end_key), or all keys starting
+// with prefix if prefix is set. An empty end_key means no upper bound and
+// limit = 0 means no limit. To resume, pass the continuation_token of the
+// previous response with the same range.
+type ScanRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	StartKey          string                 `protobuf:"bytes,1,opt,name=start_key,json=startKey,proto3" json:"start_key,omitempty"`
+	EndKey            string                 `protobuf:"bytes,2,opt,name=end_key,json=endKey,proto3" json:"end_key,omitempty"`
+	Prefix            string                 `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Limit             uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	ContinuationToken string                 `protobuf:"bytes,5,opt,name=continuation_token,json=continuationToken,proto3" json:"continuation_token,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_proto_storage_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ScanRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetContinuationToken() string {
+	if x != nil {
+		return x.ContinuationToken
+	}
+	return ""
+}
+
+// ScanResponse is one batch of results in key order. continuation_token is
+// set on the last batch if limit was reached and more keys remain.
+type ScanResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Kvs               []*KV                  `protobuf:"bytes,1,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	ContinuationToken string                 `protobuf:"bytes,2,opt,name=continuation_token,json=continuationToken,proto3" json:"continuation_token,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_proto_storage_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ScanResponse) GetKvs() []*KV {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetContinuationToken() string {
+	if x != nil {
+		return x.ContinuationToken
+	}
+	return ""
+}
+
+// ReadItem records a key the transaction read and the commit GSN it observed.
+// commit_gsn = 0 means the key was absent.
+type ReadItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	CommitGsn     uint64                 `protobuf:"varint,2,opt,name=commit_gsn,json=commitGsn,proto3" json:"commit_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadItem) Reset() {
+	*x = ReadItem{}
+	mi := &file_proto_storage_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadItem) ProtoMessage() {}
+
+func (x *ReadItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadItem.ProtoReflect.Descriptor instead.
+func (*ReadItem) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReadItem) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ReadItem) GetCommitGsn() uint64 {
+	if x != nil {
+		return x.CommitGsn
+	}
+	return 0
+}
+
+type TxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reads         []*ReadItem            `protobuf:"bytes,1,rep,name=reads,proto3" json:"reads,omitempty"`
+	Writes        []*KV                  `protobuf:"bytes,2,rep,name=writes,proto3" json:"writes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnRequest) Reset() {
+	*x = TxnRequest{}
+	mi := &file_proto_storage_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnRequest) ProtoMessage() {}
+
+func (x *TxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnRequest.ProtoReflect.Descriptor instead.
+func (*TxnRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TxnRequest) GetReads() []*ReadItem {
+	if x != nil {
+		return x.Reads
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetWrites() []*KV {
+	if x != nil {
+		return x.Writes
+	}
+	return nil
+}
+
+type TxnResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ok    bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// commit_gsn is 0 for a read-only transaction, which appends nothing.
+	CommitGsn     uint64 `protobuf:"varint,2,opt,name=commit_gsn,json=commitGsn,proto3" json:"commit_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnResponse) Reset() {
+	*x = TxnResponse{}
+	mi := &file_proto_storage_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnResponse) ProtoMessage() {}
+
+func (x *TxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnResponse.ProtoReflect.Descriptor instead.
+func (*TxnResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TxnResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *TxnResponse) GetCommitGsn() uint64 {
+	if x != nil {
+		return x.CommitGsn
+	}
+	return 0
+}
+
+// WatchRequest selects the given keys, or all keys starting with prefix if
+// prefix is set; with neither, every key is watched. start_gsn = 0 starts at
+// the current commit; otherwise changes are sent from the first commit after
+// start_gsn, so a client resumes by passing the last commit_gsn it received.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	StartGsn      uint64                 `protobuf:"varint,3,opt,name=start_gsn,json=startGsn,proto3" json:"start_gsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_proto_storage_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetStartGsn() uint64 {
+	if x != nil {
+		return x.StartGsn
+	}
+	return 0
+}
+
+// WatchResponse carries the matching writes of one commit. Responses arrive
+// in commit GSN order.
+type WatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommitGsn     uint64                 `protobuf:"varint,1,opt,name=commit_gsn,json=commitGsn,proto3" json:"commit_gsn,omitempty"`
+	Kvs           []*KV                  `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_proto_storage_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchResponse) GetCommitGsn() uint64 {
+	if x != nil {
+		return x.CommitGsn
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetKvs() []*KV {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+// ChangesRequest resumes a change feed after cursor, the cursor of the last
+// ChangeEvent the consumer processed. An empty cursor starts with the next
+// commit appended.
+type ChangesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cursor        string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangesRequest) Reset() {
+	*x = ChangesRequest{}
+	mi := &file_proto_storage_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangesRequest) ProtoMessage() {}
+
+func (x *ChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangesRequest.ProtoReflect.Descriptor instead.
+func (*ChangesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ChangesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+// ChangeEvent is one committed transaction with the values it wrote.
+type ChangeEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	CommitGsn uint64                 `protobuf:"varint,1,opt,name=commit_gsn,json=commitGsn,proto3" json:"commit_gsn,omitempty"`
+	TxnId     string                 `protobuf:"bytes,2,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	Kvs       []*KV                  `protobuf:"bytes,3,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	// cursor resumes the feed right after this event.
+	Cursor        string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_proto_storage_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChangeEvent) GetCommitGsn() uint64 {
+	if x != nil {
+		return x.CommitGsn
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetKvs() []*KV {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 var File_proto_storage_proto protoreflect.FileDescriptor
 
 const file_proto_storage_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/storage.proto\x12\astorage\",\n" +
+	"\x13proto/storage.proto\x12\astorage\"8\n" +
+	"\tRecordRef\x12\x10\n" +
+	"\x03gsn\x18\x01 \x01(\x04R\x03gsn\x12\x19\n" +
+	"\bshard_id\x18\x02 \x01(\rR\ashardId\"D\n" +
 	"\x02KV\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\fR\x05value\"0\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\bR\x06delete\"_\n" +
 	"\x0fMultiPutRequest\x12\x1d\n" +
-	"\x03kvs\x18\x01 \x03(\v2\v.storage.KVR\x03kvs\"\"\n" +
+	"\x03kvs\x18\x01 \x03(\v2\v.storage.KVR\x03kvs\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\"\xc7\x01\n" +
 	"\x10MultiPutResponse\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\"%\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x1d\n" +
+	"\n" +
+	"commit_gsn\x18\x02 \x01(\x04R\tcommitGsn\x127\n" +
+	"\x04refs\x18\x03 \x03(\v2#.storage.MultiPutResponse.RefsEntryR\x04refs\x1aK\n" +
+	"\tRefsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x05value\x18\x02 \x01(\v2\x12.storage.RecordRefR\x05value:\x028\x01\"(\n" +
+	"\x12MultiDeleteRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\"%\n" +
+	"\x13MultiDeleteResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"n\n" +
 	"\x0fMultiGetRequest\x12\x12\n" +
-	"\x04keys\x18\x01 \x03(\tR\x04keys\"\x8c\x01\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12!\n" +
+	"\fsnapshot_gsn\x18\x02 \x01(\x04R\vsnapshotGsn\x12$\n" +
+	"\x0emin_commit_gsn\x18\x03 \x01(\x04R\fminCommitGsn\"\x97\x02\n" +
 	"\x10MultiGetResponse\x12=\n" +
-	"\x06values\x18\x01 \x03(\v2%.storage.MultiGetResponse.ValuesEntryR\x06values\x1a9\n" +
+	"\x06values\x18\x01 \x03(\v2%.storage.MultiGetResponse.ValuesEntryR\x06values\x12J\n" +
+	"\vcommit_gsns\x18\x02 \x03(\v2).storage.MultiGetResponse.CommitGsnsEntryR\n" +
+	"commitGsns\x1a9\n" +
 	"\vValuesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x012\x8b\x01\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\x1a=\n" +
+	"\x0fCommitGsnsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\xa0\x01\n" +
+	"\vScanRequest\x12\x1b\n" +
+	"\tstart_key\x18\x01 \x01(\tR\bstartKey\x12\x17\n" +
+	"\aend_key\x18\x02 \x01(\tR\x06endKey\x12\x16\n" +
+	"\x06prefix\x18\x03 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\x12-\n" +
+	"\x12continuation_token\x18\x05 \x01(\tR\x11continuationToken\"\\\n" +
+	"\fScanResponse\x12\x1d\n" +
+	"\x03kvs\x18\x01 \x03(\v2\v.storage.KVR\x03kvs\x12-\n" +
+	"\x12continuation_token\x18\x02 \x01(\tR\x11continuationToken\";\n" +
+	"\bReadItem\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1d\n" +
+	"\n" +
+	"commit_gsn\x18\x02 \x01(\x04R\tcommitGsn\"Z\n" +
+	"\n" +
+	"TxnRequest\x12'\n" +
+	"\x05reads\x18\x01 \x03(\v2\x11.storage.ReadItemR\x05reads\x12#\n" +
+	"\x06writes\x18\x02 \x03(\v2\v.storage.KVR\x06writes\"<\n" +
+	"\vTxnResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x1d\n" +
+	"\n" +
+	"commit_gsn\x18\x02 \x01(\x04R\tcommitGsn\"W\n" +
+	"\fWatchRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12\x1b\n" +
+	"\tstart_gsn\x18\x03 \x01(\x04R\bstartGsn\"M\n" +
+	"\rWatchResponse\x12\x1d\n" +
+	"\n" +
+	"commit_gsn\x18\x01 \x01(\x04R\tcommitGsn\x12\x1d\n" +
+	"\x03kvs\x18\x02 \x03(\v2\v.storage.KVR\x03kvs\"(\n" +
+	"\x0eChangesRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\"z\n" +
+	"\vChangeEvent\x12\x1d\n" +
+	"\n" +
+	"commit_gsn\x18\x01 \x01(\x04R\tcommitGsn\x12\x15\n" +
+	"\x06txn_id\x18\x02 \x01(\tR\x05txnId\x12\x1d\n" +
+	"\x03kvs\x18\x03 \x03(\v2\v.storage.KVR\x03kvs\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor2\xb4\x03\n" +
 	"\aStorage\x12?\n" +
 	"\bMultiPut\x12\x18.storage.MultiPutRequest\x1a\x19.storage.MultiPutResponse\x12?\n" +
-	"\bMultiGet\x12\x18.storage.MultiGetRequest\x1a\x19.storage.MultiGetResponseB\x13Z\x11./proto/storagepbb\x06proto3"
+	"\bMultiGet\x12\x18.storage.MultiGetRequest\x1a\x19.storage.MultiGetResponse\x12H\n" +
+	"\vMultiDelete\x12\x1b.storage.MultiDeleteRequest\x1a\x1c.storage.MultiDeleteResponse\x125\n" +
+	"\x04Scan\x12\x14.storage.ScanRequest\x1a\x15.storage.ScanResponse0\x01\x120\n" +
+	"\x03Txn\x12\x13.storage.TxnRequest\x1a\x14.storage.TxnResponse\x128\n" +
+	"\x05Watch\x12\x15.storage.WatchRequest\x1a\x16.storage.WatchResponse0\x01\x12:\n" +
+	"\aChanges\x12\x17.storage.ChangesRequest\x1a\x14.storage.ChangeEvent0\x01B\x13Z\x11./proto/storagepbb\x06proto3"
 
 var (
 	file_proto_storage_proto_rawDescOnce sync.Once
@@ -284,27 +1102,59 @@ func file_proto_storage_proto_rawDescGZIP() []byte {
 	return file_proto_storage_proto_rawDescData
 }
 
-var file_proto_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_proto_storage_proto_goTypes = []any{
-	(*KV)(nil),               // 0: storage.KV
-	(*MultiPutRequest)(nil),  // 1: storage.MultiPutRequest
-	(*MultiPutResponse)(nil), // 2: storage.MultiPutResponse
-	(*MultiGetRequest)(nil),  // 3: storage.MultiGetRequest
-	(*MultiGetResponse)(nil), // 4: storage.MultiGetResponse
-	nil,                      // 5: storage.MultiGetResponse.ValuesEntry
+	(*RecordRef)(nil),           // 0: storage.RecordRef
+	(*KV)(nil),                  // 1: storage.KV
+	(*MultiPutRequest)(nil),     // 2: storage.MultiPutRequest
+	(*MultiPutResponse)(nil),    // 3: storage.MultiPutResponse
+	(*MultiDeleteRequest)(nil),  // 4: storage.MultiDeleteRequest
+	(*MultiDeleteResponse)(nil), // 5: storage.MultiDeleteResponse
+	(*MultiGetRequest)(nil),     // 6: storage.MultiGetRequest
+	(*MultiGetResponse)(nil),    // 7: storage.MultiGetResponse
+	(*ScanRequest)(nil),         // 8: storage.ScanRequest
+	(*ScanResponse)(nil),        // 9: storage.ScanResponse
+	(*ReadItem)(nil),            // 10: storage.ReadItem
+	(*TxnRequest)(nil),          // 11: storage.TxnRequest
+	(*TxnResponse)(nil),         // 12: storage.TxnResponse
+	(*WatchRequest)(nil),        // 13: storage.WatchRequest
+	(*WatchResponse)(nil),       // 14: storage.WatchResponse
+	(*ChangesRequest)(nil),      // 15: storage.ChangesRequest
+	(*ChangeEvent)(nil),         // 16: storage.ChangeEvent
+	nil,                         // 17: storage.MultiPutResponse.RefsEntry
+	nil,                         // 18: storage.MultiGetResponse.ValuesEntry
+	nil,                         // 19: storage.MultiGetResponse.CommitGsnsEntry
 }
 var file_proto_storage_proto_depIdxs = []int32{
-	0, // 0: storage.MultiPutRequest.kvs:type_name -> storage.KV
-	5, // 1: storage.MultiGetResponse.values:type_name -> storage.MultiGetResponse.ValuesEntry
-	1, // 2: storage.Storage.MultiPut:input_type -> storage.MultiPutRequest
-	3, // 3: storage.Storage.MultiGet:input_type -> storage.MultiGetRequest
-	2, // 4: storage.Storage.MultiPut:output_type -> storage.MultiPutResponse
-	4, // 5: storage.Storage.MultiGet:output_type -> storage.MultiGetResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	1,  // 0: storage.MultiPutRequest.kvs:type_name -> storage.KV
+	17, // 1: storage.MultiPutResponse.refs:type_name -> storage.MultiPutResponse.RefsEntry
+	18, // 2: storage.MultiGetResponse.values:type_name -> storage.MultiGetResponse.ValuesEntry
+	19, // 3: storage.MultiGetResponse.commit_gsns:type_name -> storage.MultiGetResponse.CommitGsnsEntry
+	1,  // 4: storage.ScanResponse.kvs:type_name -> storage.KV
+	10, // 5: storage.TxnRequest.reads:type_name -> storage.ReadItem
+	1,  // 6: storage.TxnRequest.writes:type_name -> storage.KV
+	1,  // 7: storage.WatchResponse.kvs:type_name -> storage.KV
+	1,  // 8: storage.ChangeEvent.kvs:type_name -> storage.KV
+	0,  // 9: storage.MultiPutResponse.RefsEntry.value:type_name -> storage.RecordRef
+	2,  // 10: storage.Storage.MultiPut:input_type -> storage.MultiPutRequest
+	6,  // 11: storage.Storage.MultiGet:input_type -> storage.MultiGetRequest
+	4,  // 12: storage.Storage.MultiDelete:input_type -> storage.MultiDeleteRequest
+	8,  // 13: storage.Storage.Scan:input_type -> storage.ScanRequest
+	11, // 14: storage.Storage.Txn:input_type -> storage.TxnRequest
+	13, // 15: storage.Storage.Watch:input_type -> storage.WatchRequest
+	15, // 16: storage.Storage.Changes:input_type -> storage.ChangesRequest
+	3,  // 17: storage.Storage.MultiPut:output_type -> storage.MultiPutResponse
+	7,  // 18: storage.Storage.MultiGet:output_type -> storage.MultiGetResponse
+	5,  // 19: storage.Storage.MultiDelete:output_type -> storage.MultiDeleteResponse
+	9,  // 20: storage.Storage.Scan:output_type -> storage.ScanResponse
+	12, // 21: storage.Storage.Txn:output_type -> storage.TxnResponse
+	14, // 22: storage.Storage.Watch:output_type -> storage.WatchResponse
+	16, // 23: storage.Storage.Changes:output_type -> storage.ChangeEvent
+	17, // [17:24] is the sub-list for method output_type
+	10, // [10:17] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_storage_proto_init() }
@@ -318,7 +1168,7 @@ func file_proto_storage_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_storage_proto_rawDesc), len(file_proto_storage_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
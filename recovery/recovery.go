@@ -0,0 +1,209 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/chn0318/logstore/checkpoint"
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// progressEvery controls how often (in replayed commits) progress is logged.
+const progressEvery = 10000
+
+// Result summarizes a completed replay.
+type Result struct {
+	// Checkpoint is true if the map was seeded from a checkpoint file, in
+	// which case CheckpointGSN is the MaxCommitGSN it covered.
+	Checkpoint    bool
+	CheckpointGSN uint64
+
+	FromGSN      uint64
+	ToGSN        uint64
+	Commits      int
+	MaxCommitGSN uint64
+	Elapsed      time.Duration
+}
+
+// Replay rebuilds ms by feeding every COMMIT record in [fromGSN, Tail()] into
+// MapService.ApplyCommit. It must finish before the server starts taking
+// requests, otherwise reads could observe a partially rebuilt map.
+func Replay(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService, fromGSN uint64) (Result, error) {
+	start := time.Now()
+	r, err := ms.BeginReplay(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	reachedTail := false
+	defer func() { r.Done(reachedTail) }()
+	_, tail, err := sharedlog.Bounds(ctx, l)
+	if err != nil {
+		return Result{}, fmt.Errorf("recovery: log bounds: %w", err)
+	}
+	res := Result{
+		FromGSN: fromGSN,
+		ToGSN:   tail,
+	}
+	if res.ToGSN < fromGSN {
+		r.Advance(fromGSN)
+		reachedTail = true
+		res.MaxCommitGSN = ms.MaxCommitGSN()
+		return res, nil
+	}
+
+	log.Printf("recovery: replaying commits in [%d, %d]", res.FromGSN, res.ToGSN)
+	err = l.ReplayCommits(ctx, res.FromGSN, res.ToGSN, func(commitGSN uint64, rec sharedlog.CommitRecord) error {
+		Apply(ms, commitGSN, rec)
+		r.Advance(commitGSN + 1)
+
+		res.Commits++
+		if res.Commits%progressEvery == 0 {
+			log.Printf("recovery: %d commits replayed, at gsn=%d/%d", res.Commits, commitGSN, res.ToGSN)
+		}
+		return nil
+	})
+	res.MaxCommitGSN = ms.MaxCommitGSN()
+	res.Elapsed = time.Since(start)
+	if err != nil {
+		return res, err
+	}
+	r.Advance(res.ToGSN + 1)
+	reachedTail = true
+
+	log.Printf("recovery: done, %d commits replayed in %v, max commit gsn=%d",
+		res.Commits, res.Elapsed, res.MaxCommitGSN)
+	return res, nil
+}
+
+// FromCheckpoint seeds ms from the checkpoint at path, if any, and then
+// replays only the commits after the checkpoint's MaxCommitGSN. Without a
+// checkpoint it falls back to a full replay from the log head.
+func FromCheckpoint(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService, path string) (Result, error) {
+	snap, found, err := checkpoint.Load(path)
+	if err != nil {
+		return Result{}, err
+	}
+	// 拿不到日志的边界时不能当成空日志，否则会从一个空 map 开始提供服务
+	head, _, err := sharedlog.Bounds(ctx, l)
+	if err != nil {
+		return Result{}, fmt.Errorf("recovery: log bounds: %w", err)
+	}
+	from := head
+	// An empty snapshot with MaxCommitGSN=0 has not applied anything yet, so
+	// the commit at GSN 0 (if any) must still be replayed.
+	if found && (snap.MaxCommitGSN > 0 || len(snap.Keys) > 0) {
+		if head > snap.MaxCommitGSN+1 {
+			return Result{}, fmt.Errorf("recovery: log is trimmed up to gsn %d, past checkpoint %s at gsn %d",
+				head, path, snap.MaxCommitGSN)
+		}
+		ms.Restore(snap)
+		if next := snap.MaxCommitGSN + 1; next > from {
+			from = next
+		}
+		log.Printf("recovery: loaded checkpoint %s, %d keys, max commit gsn=%d",
+			path, len(snap.Keys), snap.MaxCommitGSN)
+	}
+
+	res, err := Replay(ctx, l, ms, from)
+	res.Checkpoint = found
+	res.CheckpointGSN = snap.MaxCommitGSN
+	return res, err
+}
+
+// CatchUp applies the commits from ms.ReplayNext() up to the tail of l. It
+// is for logs written by other servers too, where their commits reach the
+// local map only through the log, and for commits of unknown outcome. It
+// relies on re-applying a commit being harmless.
+func CatchUp(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService) (int, error) {
+	return catchUp(ctx, l, ms, math.MaxUint64)
+}
+
+// CatchUpTo is CatchUp up to gsn, or up to the tail if that is lower. It
+// reports whether every commit up to gsn has been applied.
+func CatchUpTo(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService, gsn uint64) (bool, error) {
+	if ms.ReplayNext() > gsn {
+		return true, nil
+	}
+	_, err := catchUp(ctx, l, ms, gsn)
+	return ms.ReplayNext() > gsn, err
+}
+
+func catchUp(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService, upTo uint64) (int, error) {
+	r, err := ms.BeginReplay(ctx)
+	if err != nil {
+		return 0, err
+	}
+	reachedTail := false
+	defer func() { r.Done(reachedTail) }()
+	n := 0
+	for {
+		head, tail, err := sharedlog.Bounds(ctx, l)
+		if err != nil {
+			return n, err
+		}
+		from := r.Next()
+		if from < head {
+			// 还没重放到的 commit 已经被裁掉了，只能从 head 继续；从没重放过的 map 直接从 head 开始
+			if from > 0 {
+				log.Printf("recovery: commits in [%d, %d) were trimmed before they were replayed", from, head)
+			}
+			r.Advance(head)
+			from = head
+		}
+		to := min(tail, upTo)
+		if to < from {
+			reachedTail = to == tail
+			return n, nil
+		}
+		err = l.ReplayCommits(ctx, from, to, func(commitGSN uint64, rec sharedlog.CommitRecord) error {
+			Apply(ms, commitGSN, rec)
+			r.Advance(commitGSN + 1)
+			n++
+			return nil
+		})
+		if err == nil {
+			r.Advance(to + 1)
+			reachedTail = to == tail
+			return n, nil
+		}
+		// 有的后端读到被裁掉的 GSN 会报错：日志在重放途中被裁过就从新的 head 继续
+		if head, _, herr := sharedlog.Bounds(ctx, l); herr == nil && ctx.Err() == nil && head > r.Next() {
+			continue
+		}
+		return n, err
+	}
+}
+
+// Apply applies a commit read from the log, including the client request it
+// answered, if any. Applying a commit again is harmless.
+func Apply(ms *mapservice.MapService, commitGSN uint64, rec sharedlog.CommitRecord) {
+	if rec.ClientID != "" {
+		ms.RecordRequest(rec.ClientID, rec.Seq, commitGSN)
+	}
+	ms.ApplyCommit(commitGSN, mapservice.EntriesFromLog(rec.Entries))
+}
+
+// Follow runs CatchUp every interval while active reports true, or while a
+// local commit of unknown outcome needs it, until ctx is done. It keeps the
+// map of a replica that does not take writes close to the writer's.
+func Follow(ctx context.Context, l sharedlog.SharedLog, ms *mapservice.MapService, interval time.Duration, active func() bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !active() && !ms.NeedsReplay() {
+				continue
+			}
+			if _, err := CatchUp(ctx, l, ms); err != nil && ctx.Err() == nil {
+				log.Printf("recovery: follow: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
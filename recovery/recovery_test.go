@@ -0,0 +1,103 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// strictLog fails replays that start below the head, like backends that
+// cannot read trimmed GSNs, and fails a replay after failAfter commits.
+type strictLog struct {
+	*memorylog.MemoryLog
+	failAfter int
+}
+
+var errReplay = errors.New("replay failed")
+
+func (l *strictLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	if head := l.Head(ctx); from < head {
+		return fmt.Errorf("gsn %d: %w", from, sharedlog.ErrTrimmed)
+	}
+	n := 0
+	return l.MemoryLog.ReplayCommits(ctx, from, to, func(gsn uint64, rec sharedlog.CommitRecord) error {
+		if l.failAfter > 0 && n == l.failAfter {
+			return errReplay
+		}
+		n++
+		return handler(gsn, rec)
+	})
+}
+
+func commit(t *testing.T, l sharedlog.SharedLog, key string) uint64 {
+	t.Helper()
+	gsn, err := l.AppendCommit(context.Background(), sharedlog.CommitRecord{Entries: []sharedlog.CommitEntry{{Key: key, Tombstone: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gsn
+}
+
+func TestCatchUpTrimmed(t *testing.T) {
+	ctx := context.Background()
+	l := &strictLog{MemoryLog: memorylog.NewMemoryLog()}
+	ms := mapservice.NewMapService()
+	commit(t, l, "a")
+	if _, err := Replay(ctx, l, ms, l.Head(ctx)); err != nil {
+		t.Fatal(err)
+	}
+
+	// b is trimmed before this server replays it
+	b := commit(t, l, "b")
+	c := commit(t, l, "c")
+	if err := l.Trim(ctx, b+1); err != nil {
+		t.Fatal(err)
+	}
+	n, err := CatchUp(ctx, l, ms)
+	if err != nil || n != 1 {
+		t.Fatalf("CatchUp = %d, %v, want the one commit left", n, err)
+	}
+	if next := ms.ReplayNext(); next != c+1 {
+		t.Fatalf("ReplayNext = %d, want %d", next, c+1)
+	}
+	if _, ok := ms.GetMetas([]string{"c"})["c"]; !ok {
+		t.Fatal("commit after the trimmed one not applied")
+	}
+}
+
+func TestCatchUpProgress(t *testing.T) {
+	ctx := context.Background()
+	l := &strictLog{MemoryLog: memorylog.NewMemoryLog()}
+	ms := mapservice.NewMapService()
+	var gsns []uint64
+	for i := 0; i < 5; i++ {
+		gsns = append(gsns, commit(t, l, fmt.Sprint(i)))
+	}
+
+	// a replay that stops midway keeps what it applied
+	l.failAfter = 2
+	if _, err := CatchUp(ctx, l, ms); !errors.Is(err, errReplay) {
+		t.Fatalf("err = %v, want %v", err, errReplay)
+	}
+	if next := ms.ReplayNext(); next != gsns[1]+1 {
+		t.Fatalf("ReplayNext = %d after 2 commits, want %d", next, gsns[1]+1)
+	}
+	done, err := CatchUpTo(ctx, l, ms, gsns[3])
+	if err != nil || !done {
+		t.Fatalf("CatchUpTo(%d) = %v, %v", gsns[3], done, err)
+	}
+	if next := ms.ReplayNext(); next != gsns[3]+1 {
+		t.Fatalf("ReplayNext = %d, want %d", next, gsns[3]+1)
+	}
+	if done, err := CatchUpTo(ctx, l, ms, gsns[4]+1); err != nil || done {
+		t.Fatalf("CatchUpTo past the tail = %v, %v, want not done", done, err)
+	}
+	if metas := ms.GetMetas([]string{"4"}); len(metas) != 1 {
+		t.Fatal("last commit not applied")
+	}
+}
@@ -0,0 +1,184 @@
+package cachelog
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+var (
+	_ sharedlog.SharedLog    = (*CachedLog)(nil)
+	_ sharedlog.BoundsReader = (*CachedLog)(nil)
+)
+
+// entryOverhead approximates the bookkeeping cost of one cached record (map
+// slot, list element, RecordRef), so that many tiny values still count
+// against the budget.
+const entryOverhead = 96
+
+// CachedLog wraps a SharedLog with a read-through cache of decoded DATA
+// records. Log records never change once written, so an entry only has to
+// go when it is evicted for space or trimmed from the log.
+type CachedLog struct {
+	sharedlog.SharedLog
+
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	lru      *list.List // front = most recently used
+	entries  map[sharedlog.RecordRef]*list.Element
+	stats    Stats
+}
+
+type cacheEntry struct {
+	ref  sharedlog.RecordRef
+	rec  sharedlog.DataRecord
+	size int64
+}
+
+// Stats are cumulative cache counters plus the current occupancy.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// NewCachedLog caches up to maxBytes worth of DATA records read from or
+// appended to inner.
+func NewCachedLog(inner sharedlog.SharedLog, maxBytes int64) *CachedLog {
+	return &CachedLog{
+		SharedLog: inner,
+		maxBytes:  maxBytes,
+		lru:       list.New(),
+		entries:   make(map[sharedlog.RecordRef]*list.Element),
+	}
+}
+
+func (c *CachedLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	ref, err := c.SharedLog.AppendData(ctx, rec)
+	if err != nil {
+		return ref, err
+	}
+	c.add(ref, rec)
+	return ref, nil
+}
+
+func (c *CachedLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	refs, err := c.SharedLog.AppendDataBatch(ctx, recs)
+	if refs == nil {
+		return nil, err
+	}
+	errs := sharedlog.RecordErrors(len(recs), err)
+	for i, ref := range refs {
+		if errs[i] == nil {
+			c.add(ref, recs[i])
+		}
+	}
+	return refs, err
+}
+
+func (c *CachedLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	refs, err := c.SharedLog.AppendBatch(ctx, recs)
+	if refs == nil {
+		return nil, err
+	}
+	errs := sharedlog.RecordErrors(len(recs), err)
+	for i, ref := range refs {
+		if recs[i].Type == sharedlog.RecordTypeData && errs[i] == nil {
+			c.add(ref, *recs[i].Data)
+		}
+	}
+	return refs, err
+}
+
+func (c *CachedLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	if rec, ok := c.get(ref); ok {
+		return rec, nil
+	}
+	rec, err := c.SharedLog.ReadData(ctx, ref)
+	if err != nil {
+		return rec, err
+	}
+	c.add(ref, rec)
+	return rec, nil
+}
+
+// Trim trims the underlying log and drops cached records below upTo, so that
+// reads of trimmed records fail the same way with or without the cache.
+func (c *CachedLog) Trim(ctx context.Context, upTo uint64) error {
+	if err := c.SharedLog.Trim(ctx, upTo); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ref, el := range c.entries {
+		if ref.GSN < upTo {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// Bounds forwards to the underlying log, see sharedlog.Bounds.
+func (c *CachedLog) Bounds(ctx context.Context) (head, tail uint64, err error) {
+	return sharedlog.Bounds(ctx, c.SharedLog)
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *CachedLog) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Entries = len(c.entries)
+	st.Bytes = c.bytes
+	return st
+}
+
+func (c *CachedLog) get(ref sharedlog.RecordRef) (sharedlog.DataRecord, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[ref]
+	if !ok {
+		c.stats.Misses++
+		return sharedlog.DataRecord{}, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry).rec, true
+}
+
+// add caches rec under ref. Records larger than the whole budget are not
+// admitted. The value is copied because appended records may share their
+// buffer with the caller.
+func (c *CachedLog) add(ref sharedlog.RecordRef, rec sharedlog.DataRecord) {
+	size := int64(len(rec.Key)+len(rec.Value)+len(rec.TxnID)) + entryOverhead
+	if size > c.maxBytes {
+		return
+	}
+	rec.Value = bytes.Clone(rec.Value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[ref]; ok {
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[ref] = c.lru.PushFront(&cacheEntry{ref: ref, rec: rec, size: size})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// caller holds c.mu
+func (c *CachedLog) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.ref)
+	c.bytes -= e.size
+}
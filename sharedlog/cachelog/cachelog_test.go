@@ -0,0 +1,120 @@
+package cachelog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// countingLog counts the reads that reach the inner log.
+type countingLog struct {
+	sharedlog.SharedLog
+	reads int
+}
+
+func (l *countingLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	l.reads++
+	return l.SharedLog.ReadData(ctx, ref)
+}
+
+// recordSize is what a record with a one-byte key and value costs the cache.
+const recordSize = 2 + entryOverhead
+
+func appendData(t *testing.T, l sharedlog.SharedLog, key string) sharedlog.RecordRef {
+	t.Helper()
+	ref, err := l.AppendData(context.Background(), sharedlog.DataRecord{Key: key, Value: []byte("v")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestAppendPopulates(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingLog{SharedLog: memorylog.NewMemoryLog()}
+	c := NewCachedLog(inner, 10*recordSize)
+	ref := appendData(t, c, "a")
+
+	rec, err := c.ReadData(ctx, ref)
+	if err != nil || rec.Key != "a" {
+		t.Fatalf("ReadData = %v, %v", rec, err)
+	}
+	if inner.reads != 0 {
+		t.Fatalf("%d reads reached the log after an append, want 0", inner.reads)
+	}
+	if st := c.Stats(); st.Hits != 1 || st.Misses != 0 || st.Entries != 1 || st.Bytes != recordSize {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestEvictionByBytes(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingLog{SharedLog: memorylog.NewMemoryLog()}
+	c := NewCachedLog(inner, 2*recordSize)
+	a := appendData(t, c, "a")
+	b := appendData(t, c, "b")
+	// touching a makes b the least recently used
+	if _, err := c.ReadData(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	appendData(t, c, "c")
+
+	st := c.Stats()
+	if st.Evictions != 1 || st.Entries != 2 || st.Bytes != 2*recordSize {
+		t.Fatalf("Stats after overflow = %+v", st)
+	}
+	if _, err := c.ReadData(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if inner.reads != 1 {
+		t.Fatalf("read of the evicted record: %d reads reached the log, want 1", inner.reads)
+	}
+	// a record larger than the whole budget is never admitted
+	big, err := c.AppendData(ctx, sharedlog.DataRecord{Key: "big", Value: make([]byte, 2*recordSize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadData(ctx, big); err != nil {
+		t.Fatal(err)
+	}
+	if st := c.Stats(); st.Bytes > 2*recordSize {
+		t.Fatalf("cache holds %d bytes over a budget of %d", st.Bytes, 2*recordSize)
+	}
+}
+
+func TestReadThroughStats(t *testing.T) {
+	ctx := context.Background()
+	inner := memorylog.NewMemoryLog()
+	ref := appendData(t, inner, "a")
+	c := NewCachedLog(inner, 10*recordSize)
+	for i := 0; i < 3; i++ {
+		if _, err := c.ReadData(ctx, ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := c.Stats(); st.Misses != 1 || st.Hits != 2 || st.Evictions != 0 || st.Entries != 1 {
+		t.Fatalf("Stats = %+v, want 1 miss then 2 hits", st)
+	}
+}
+
+func TestTrimInvalidates(t *testing.T) {
+	ctx := context.Background()
+	c := NewCachedLog(memorylog.NewMemoryLog(), 10*recordSize)
+	a := appendData(t, c, "a")
+	b := appendData(t, c, "b")
+	if err := c.Trim(ctx, b.GSN); err != nil {
+		t.Fatal(err)
+	}
+	// served from the cache this read would succeed
+	if _, err := c.ReadData(ctx, a); err == nil {
+		t.Fatal("read of a trimmed record succeeded")
+	}
+	if _, err := c.ReadData(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if st := c.Stats(); st.Entries != 1 || st.Bytes != recordSize {
+		t.Fatalf("Stats after Trim = %+v", st)
+	}
+}
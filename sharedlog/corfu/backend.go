@@ -0,0 +1,74 @@
+package corfu
+
+import (
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// The "corfu" backend connects to the sequencer at corfu-sequencer and the
+// storage units listed in corfu-units. Without corfu-sequencer it runs the
+// whole log in-process with corfu-local-units (default 3) in-memory units.
+func init() {
+	sharedlog.RegisterBackend("corfu", func(cfg sharedlog.Config, _ sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		opts := DefaultOptions()
+		if cfg.IsSet("corfu-hole-timeout") {
+			opts.HoleTimeout = cfg.GetDuration("corfu-hole-timeout")
+		}
+		seqAddr := cfg.GetString("corfu-sequencer")
+		if seqAddr == "" {
+			n := 3
+			if cfg.IsSet("corfu-local-units") {
+				n = cfg.GetInt("corfu-local-units")
+			}
+			log.Printf("corfu-sequencer not set, running an in-process corfu log")
+			return NewLocal(n, opts)
+		}
+
+		dial := func(addr string) (*grpc.ClientConn, error) {
+			return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		}
+		var conns []*grpc.ClientConn
+		closeConns := func() error {
+			var err error
+			for _, c := range conns {
+				if e := c.Close(); err == nil {
+					err = e
+				}
+			}
+			return err
+		}
+		seqConn, err := dial(seqAddr)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, seqConn)
+		var units []StorageUnit
+		for _, addr := range cfg.GetStringSlice("corfu-units") {
+			conn, err := dial(addr)
+			if err != nil {
+				closeConns()
+				return nil, err
+			}
+			conns = append(conns, conn)
+			units = append(units, NewRemoteUnit(conn))
+		}
+		l, err := NewCorfuLog(NewRemoteSequencer(seqConn), units, opts)
+		if err != nil {
+			closeConns()
+			return nil, err
+		}
+		return &ownedCorfuLog{CorfuLog: l, closeConns: closeConns}, nil
+	})
+}
+
+// ownedCorfuLog is a CorfuLog that owns its connections.
+type ownedCorfuLog struct {
+	*CorfuLog
+	closeConns func() error
+}
+
+func (l *ownedCorfuLog) Close() error { return l.closeConns() }
@@ -0,0 +1,352 @@
+// Package corfu is a CORFU-style SharedLog: a sequencer reserves GSNs and
+// clients write each record directly to the storage unit owning its GSN,
+// with GSNs striped round-robin over the units. A client that reserves a GSN
+// and never writes it leaves a hole; readers that wait on a hole for longer
+// than Options.HoleTimeout fill it with junk and move on, and the slow
+// writer then retries at a fresh GSN.
+//
+// The sequencer and units can run in-process (NewLocal) or as separate
+// processes reached over gRPC (see cmd/corfu, RemoteSequencer, RemoteUnit).
+// Each stripe has a single unit; there is no replication.
+package corfu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+var (
+	_ sharedlog.SharedLog     = (*CorfuLog)(nil)
+	_ sharedlog.RecordScanner = (*CorfuLog)(nil)
+	_ sharedlog.BoundsReader  = (*CorfuLog)(nil)
+)
+
+type Options struct {
+	// HoleTimeout is how long a reader waits on an unwritten position below
+	// the tail before filling it with junk.
+	HoleTimeout time.Duration
+	// HolePoll is how often the reader re-reads a hole while waiting.
+	HolePoll time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		HoleTimeout: time.Second,
+		HolePoll:    5 * time.Millisecond,
+	}
+}
+
+// CorfuLog is the client side of the log.
+type CorfuLog struct {
+	seq   Sequencer
+	units []StorageUnit
+	opts  Options
+
+	// last values seen, returned by Head/Tail when the servers are unreachable
+	mu       sync.Mutex
+	lastHead uint64
+	lastTail uint64
+}
+
+func NewCorfuLog(seq Sequencer, units []StorageUnit, opts Options) (*CorfuLog, error) {
+	if len(units) == 0 {
+		return nil, errors.New("corfu: no storage units")
+	}
+	return &CorfuLog{
+		seq:      seq,
+		units:    units,
+		opts:     opts,
+		lastHead: 1,
+	}, nil
+}
+
+// NewLocal returns a log backed by an in-process sequencer and n in-memory
+// storage units.
+func NewLocal(n int, opts Options) (*CorfuLog, error) {
+	units := make([]StorageUnit, n)
+	for i := range units {
+		units[i] = NewStorageUnit()
+	}
+	return NewCorfuLog(NewSequencer(1), units, opts)
+}
+
+func (l *CorfuLog) unitFor(gsn uint64) StorageUnit {
+	return l.units[gsn%uint64(len(l.units))]
+}
+
+func (l *CorfuLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	data, err := sharedlog.EncodeData(rec)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	gsn, err := l.append(ctx, data)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	return sharedlog.RecordRef{GSN: gsn}, nil
+}
+
+func (l *CorfuLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	data, err := sharedlog.EncodeCommit(rec)
+	if err != nil {
+		return 0, err
+	}
+	return l.append(ctx, data)
+}
+
+func (l *CorfuLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch reserves one range of GSNs for the whole batch and writes the
+// records to their units concurrently. A record whose position was filled
+// in the meantime is appended again at a fresh GSN. Failures are reported
+// per record with a *sharedlog.BatchError.
+func (l *CorfuLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	if len(recs) == 0 {
+		return nil, nil
+	}
+	payloads := make([][]byte, len(recs))
+	for i, rec := range recs {
+		data, err := sharedlog.EncodeRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = data
+	}
+
+	first, err := l.seq.Next(ctx, len(recs))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]sharedlog.RecordRef, len(recs))
+	errs := make([]error, len(recs))
+	var wg sync.WaitGroup
+	for i := range payloads {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			gsn := first + uint64(i)
+			err := l.unitFor(gsn).Write(ctx, gsn, payloads[i])
+			if errors.Is(err, ErrWritten) {
+				gsn, err = l.append(ctx, payloads[i])
+			}
+			refs[i], errs[i] = sharedlog.RecordRef{GSN: gsn}, err
+		}(i)
+	}
+	wg.Wait()
+	return sharedlog.BatchResult(refs, errs)
+}
+
+// append writes data at a fresh GSN, retrying while readers keep filling
+// the positions it reserves.
+func (l *CorfuLog) append(ctx context.Context, data []byte) (uint64, error) {
+	for {
+		gsn, err := l.seq.Next(ctx, 1)
+		if err != nil {
+			return 0, err
+		}
+		err = l.unitFor(gsn).Write(ctx, gsn, data)
+		if errors.Is(err, ErrWritten) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return gsn, nil
+	}
+}
+
+func (l *CorfuLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	data, err := l.unitFor(ref.GSN).Read(ctx, ref.GSN)
+	if err != nil {
+		return sharedlog.DataRecord{}, fmt.Errorf("gsn=%d: %w", ref.GSN, err)
+	}
+	return sharedlog.DecodeData(data)
+}
+
+// ReplayCommits scans [from, to] in GSN order. Holes are waited on and
+// eventually filled, so a crashed writer cannot stall the scan forever.
+func (l *CorfuLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	head, tail, err := l.Bounds(ctx)
+	if err != nil {
+		return err
+	}
+	if from < head {
+		from = head
+	}
+	if to > tail {
+		to = tail
+	}
+	for gsn := from; gsn <= to; gsn++ {
+		data, err := l.readOrFill(ctx, gsn)
+		if errors.Is(err, ErrJunk) || errors.Is(err, ErrTrimmed) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		rec, err := sharedlog.DecodeCommit(data)
+		if errors.Is(err, sharedlog.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := handler(gsn, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanRecords fills holes like ReplayCommits. An append that was abandoned
+// mid-write therefore either shows up in the scan or can no longer land.
+func (l *CorfuLog) ScanRecords(ctx context.Context, from, to uint64, fn func(sharedlog.RecordRef, sharedlog.Record) error) error {
+	head, tail, err := l.Bounds(ctx)
+	if err != nil {
+		return err
+	}
+	if from < head {
+		from = head
+	}
+	if to > tail {
+		to = tail
+	}
+	for gsn := from; gsn <= to; gsn++ {
+		data, err := l.readOrFill(ctx, gsn)
+		if errors.Is(err, ErrJunk) || errors.Is(err, ErrTrimmed) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		rec, err := sharedlog.DecodeRecord(data)
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := fn(sharedlog.RecordRef{GSN: gsn}, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readOrFill reads gsn, waiting up to HoleTimeout for it to be written and
+// filling it with junk (ErrJunk) after that.
+func (l *CorfuLog) readOrFill(ctx context.Context, gsn uint64) ([]byte, error) {
+	unit := l.unitFor(gsn)
+	deadline := time.Now().Add(l.opts.HoleTimeout)
+	for {
+		data, err := unit.Read(ctx, gsn)
+		if !errors.Is(err, ErrUnwritten) {
+			return data, err
+		}
+		if time.Now().After(deadline) {
+			err := unit.Fill(ctx, gsn)
+			if errors.Is(err, ErrWritten) {
+				// the writer got there first
+				return unit.Read(ctx, gsn)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrJunk
+		}
+		select {
+		case <-time.After(l.opts.HolePoll):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Subscribe polls the tail. Holes are filled by ReplayCommits as usual, so
+// a stalled append delays subscribers by at most HoleTimeout.
+func (l *CorfuLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	return sharedlog.PollCommits(ctx, l, from, sharedlog.DefaultPollInterval, handler)
+}
+
+// Head returns the lowest head over all units, falling back to the last
+// known value if a unit cannot be reached. Bounds reports that error.
+func (l *CorfuLog) Head(ctx context.Context) uint64 {
+	head, err := l.head(ctx)
+	if err != nil {
+		log.Printf("corfu: head: %v; using the last known head %d", err, head)
+	}
+	return head
+}
+
+// Tail returns the last GSN handed out by the sequencer, or the last known
+// one if the sequencer cannot be reached. Positions just below it may still
+// be in flight or holes.
+func (l *CorfuLog) Tail(ctx context.Context) uint64 {
+	tail, err := l.tail(ctx)
+	if err != nil {
+		log.Printf("corfu: tail: %v; using the last known tail %d", err, tail)
+	}
+	return tail
+}
+
+// Bounds is Head and Tail without the fallback to the last known values.
+func (l *CorfuLog) Bounds(ctx context.Context) (head, tail uint64, err error) {
+	if head, err = l.head(ctx); err != nil {
+		return 0, 0, err
+	}
+	if tail, err = l.tail(ctx); err != nil {
+		return 0, 0, err
+	}
+	return head, tail, nil
+}
+
+// head returns the lowest head over all units, or the last known head with
+// the error of a unit that cannot be reached.
+func (l *CorfuLog) head(ctx context.Context) (uint64, error) {
+	var head uint64
+	for i, u := range l.units {
+		h, err := u.Head(ctx)
+		if err != nil {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.lastHead, fmt.Errorf("unit %d: %w", i, err)
+		}
+		if i == 0 || h < head {
+			head = h
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastHead = head
+	return head, nil
+}
+
+// tail is Tail with the error of the sequencer.
+func (l *CorfuLog) tail(ctx context.Context) (uint64, error) {
+	tail, err := l.seq.Tail(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		return l.lastTail, err
+	}
+	l.lastTail = tail
+	return tail, nil
+}
+
+// Trim trims every unit below upTo.
+func (l *CorfuLog) Trim(ctx context.Context, upTo uint64) error {
+	for _, u := range l.units {
+		if err := u.Trim(ctx, upTo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close is a no-op: the caller owns the sequencer and the units.
+func (l *CorfuLog) Close() error { return nil }
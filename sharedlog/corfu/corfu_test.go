@@ -0,0 +1,210 @@
+package corfu
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.HoleTimeout = 50 * time.Millisecond
+	opts.HolePoll = time.Millisecond
+	return opts
+}
+
+func newLocal(t *testing.T) *CorfuLog {
+	t.Helper()
+	l, err := NewLocal(3, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func commitKeys(t *testing.T, l *CorfuLog, from, to uint64) []string {
+	t.Helper()
+	var keys []string
+	err := l.ReplayCommits(context.Background(), from, to, func(_ uint64, rec sharedlog.CommitRecord) error {
+		keys = append(keys, rec.Entries[0].Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func appendCommit(t *testing.T, l *CorfuLog, key string) uint64 {
+	t.Helper()
+	gsn, err := l.AppendCommit(context.Background(), sharedlog.CommitRecord{Entries: []sharedlog.CommitEntry{{Key: key}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gsn
+}
+
+func TestHoleFill(t *testing.T) {
+	ctx := context.Background()
+	l := newLocal(t)
+	appendCommit(t, l, "a")
+	// a writer reserves a position and never writes it
+	hole, err := l.seq.Next(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendCommit(t, l, "b")
+
+	if keys := commitKeys(t, l, 1, l.Tail(ctx)); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("replay over a hole = %v, want [a b]", keys)
+	}
+	if _, err := l.unitFor(hole).Read(ctx, hole); !errors.Is(err, ErrJunk) {
+		t.Fatalf("hole after replay: err = %v, want ErrJunk", err)
+	}
+	// the slow writer loses its position
+	if err := l.unitFor(hole).Write(ctx, hole, []byte("late")); !errors.Is(err, ErrWritten) {
+		t.Fatalf("late write to a filled hole: err = %v, want ErrWritten", err)
+	}
+}
+
+func TestAppendSkipsFilledPosition(t *testing.T) {
+	ctx := context.Background()
+	l := newLocal(t)
+	// a reader already filled the next position the sequencer hands out
+	next := l.Tail(ctx) + 1
+	if err := l.unitFor(next).Fill(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := l.AppendData(ctx, sharedlog.DataRecord{Key: "k", Value: []byte("v")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.GSN == next {
+		t.Fatalf("append landed on the filled position %d", next)
+	}
+	rec, err := l.ReadData(ctx, ref)
+	if err != nil || string(rec.Value) != "v" {
+		t.Fatalf("ReadData(%d) = %v, %v", ref.GSN, rec, err)
+	}
+}
+
+func TestSequencerFailover(t *testing.T) {
+	ctx := context.Background()
+	l := newLocal(t)
+	for _, k := range []string{"a", "b", "c"} {
+		appendCommit(t, l, k)
+	}
+	// a position reserved but never written stays a hole after failover
+	if _, err := l.seq.Next(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	last := appendCommit(t, l, "d")
+
+	// the sequencer is lost; a new one is rebuilt from the units
+	seq, err := RecoverSequencer(ctx, l.units)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := NewCorfuLog(seq, l.units, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail := recovered.Tail(ctx); tail != last {
+		t.Fatalf("tail after failover = %d, want %d", tail, last)
+	}
+	next := appendCommit(t, recovered, "e")
+	if next <= last {
+		t.Fatalf("append after failover at %d, reusing a position up to %d", next, last)
+	}
+	keys := commitKeys(t, recovered, 1, recovered.Tail(ctx))
+	if len(keys) != 5 || keys[4] != "e" {
+		t.Fatalf("commits after failover = %v, want [a b c d e]", keys)
+	}
+}
+
+// failingUnit rejects writes to one GSN.
+type failingUnit struct {
+	StorageUnit
+	gsn uint64
+}
+
+var errUnitDown = errors.New("unit down")
+
+func (u failingUnit) Write(ctx context.Context, gsn uint64, data []byte) error {
+	if gsn == u.gsn {
+		return errUnitDown
+	}
+	return u.StorageUnit.Write(ctx, gsn, data)
+}
+
+func TestAppendBatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	units := []StorageUnit{NewStorageUnit(), NewStorageUnit(), NewStorageUnit()}
+	units[2] = failingUnit{StorageUnit: units[2], gsn: 2}
+	l, err := NewCorfuLog(NewSequencer(1), units, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := sharedlog.DataRecords([]sharedlog.DataRecord{{Key: "a"}, {Key: "b"}, {Key: "c"}})
+	refs, err := l.AppendBatch(ctx, recs)
+	var be *sharedlog.BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want a BatchError", err)
+	}
+	errs := sharedlog.RecordErrors(len(recs), err)
+	if errs[0] != nil || !errors.Is(errs[1], errUnitDown) || errs[2] != nil {
+		t.Fatalf("per-record errors = %v, want only the second to fail", errs)
+	}
+	for _, i := range []int{0, 2} {
+		rec, err := l.ReadData(ctx, refs[i])
+		if err != nil || rec.Key != recs[i].Data.Key {
+			t.Fatalf("record %d at %d: %v, %v", i, refs[i].GSN, rec, err)
+		}
+	}
+}
+
+// flakyUnit fails Head while down is set.
+type flakyUnit struct {
+	StorageUnit
+	down *atomic.Bool
+}
+
+func (u flakyUnit) Head(ctx context.Context) (uint64, error) {
+	if u.down.Load() {
+		return 0, errUnitDown
+	}
+	return u.StorageUnit.Head(ctx)
+}
+
+func TestBoundsUnreachableUnit(t *testing.T) {
+	ctx := context.Background()
+	var down atomic.Bool
+	units := []StorageUnit{NewStorageUnit(), NewStorageUnit()}
+	units[1] = flakyUnit{StorageUnit: units[1], down: &down}
+	l, err := NewCorfuLog(NewSequencer(1), units, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendCommit(t, l, "a")
+	head, tail, err := l.Bounds(ctx)
+	if err != nil || head != 1 || tail != 1 {
+		t.Fatalf("Bounds = %d, %d, %v, want 1, 1", head, tail, err)
+	}
+
+	down.Store(true)
+	if _, _, err := sharedlog.Bounds(ctx, l); !errors.Is(err, errUnitDown) {
+		t.Fatalf("Bounds with a unit down: err = %v, want errUnitDown", err)
+	}
+	// Head falls back to the last known value
+	if h := l.Head(ctx); h != head {
+		t.Fatalf("Head with a unit down = %d, want the last known %d", h, head)
+	}
+	// a replay cannot tell which records are gone, so it fails
+	if err := l.ReplayCommits(ctx, 1, 1, func(uint64, sharedlog.CommitRecord) error { return nil }); !errors.Is(err, errUnitDown) {
+		t.Fatalf("ReplayCommits with a unit down: err = %v, want errUnitDown", err)
+	}
+}
@@ -0,0 +1,207 @@
+package corfu
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/corfupb"
+)
+
+// Storage unit errors travel as gRPC status codes.
+var unitErrCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{ErrWritten, codes.AlreadyExists},
+	{ErrUnwritten, codes.NotFound},
+	{ErrJunk, codes.FailedPrecondition},
+	{ErrTrimmed, codes.OutOfRange},
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, e := range unitErrCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, err.Error())
+		}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := status.Code(err)
+	for _, e := range unitErrCodes {
+		if code == e.code {
+			return e.err
+		}
+	}
+	return err
+}
+
+// RegisterSequencer serves seq on s.
+func RegisterSequencer(s *grpc.Server, seq Sequencer) {
+	corfupb.RegisterSequencerServer(s, &sequencerServer{seq: seq})
+}
+
+// RegisterStorageUnit serves unit on s.
+func RegisterStorageUnit(s *grpc.Server, unit StorageUnit) {
+	corfupb.RegisterStorageUnitServer(s, &unitServer{unit: unit})
+}
+
+type sequencerServer struct {
+	corfupb.UnimplementedSequencerServer
+	seq Sequencer
+}
+
+func (s *sequencerServer) Next(ctx context.Context, req *corfupb.NextRequest) (*corfupb.NextResponse, error) {
+	first, err := s.seq.Next(ctx, int(req.Count))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.NextResponse{First: first}, nil
+}
+
+func (s *sequencerServer) Tail(ctx context.Context, req *corfupb.TailRequest) (*corfupb.PositionResponse, error) {
+	tail, err := s.seq.Tail(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.PositionResponse{Gsn: tail}, nil
+}
+
+type unitServer struct {
+	corfupb.UnimplementedStorageUnitServer
+	unit StorageUnit
+}
+
+func (s *unitServer) Write(ctx context.Context, req *corfupb.WriteRequest) (*corfupb.WriteResponse, error) {
+	if err := s.unit.Write(ctx, req.Gsn, req.Data); err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.WriteResponse{}, nil
+}
+
+func (s *unitServer) Read(ctx context.Context, req *corfupb.ReadRequest) (*corfupb.ReadResponse, error) {
+	data, err := s.unit.Read(ctx, req.Gsn)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.ReadResponse{Data: data}, nil
+}
+
+func (s *unitServer) Fill(ctx context.Context, req *corfupb.FillRequest) (*corfupb.FillResponse, error) {
+	if err := s.unit.Fill(ctx, req.Gsn); err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.FillResponse{}, nil
+}
+
+func (s *unitServer) Trim(ctx context.Context, req *corfupb.TrimRequest) (*corfupb.TrimResponse, error) {
+	if err := s.unit.Trim(ctx, req.UpTo); err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.TrimResponse{}, nil
+}
+
+func (s *unitServer) Head(ctx context.Context, req *corfupb.HeadRequest) (*corfupb.PositionResponse, error) {
+	head, err := s.unit.Head(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.PositionResponse{Gsn: head}, nil
+}
+
+func (s *unitServer) Tail(ctx context.Context, req *corfupb.TailRequest) (*corfupb.PositionResponse, error) {
+	tail, err := s.unit.Tail(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &corfupb.PositionResponse{Gsn: tail}, nil
+}
+
+// RemoteSequencer is a Sequencer served by another process. The caller owns
+// the connection.
+type RemoteSequencer struct {
+	c corfupb.SequencerClient
+}
+
+func NewRemoteSequencer(conn grpc.ClientConnInterface) *RemoteSequencer {
+	return &RemoteSequencer{c: corfupb.NewSequencerClient(conn)}
+}
+
+func (r *RemoteSequencer) Next(ctx context.Context, count int) (uint64, error) {
+	resp, err := r.c.Next(ctx, &corfupb.NextRequest{Count: uint32(count)})
+	if err != nil {
+		return 0, err
+	}
+	return resp.First, nil
+}
+
+func (r *RemoteSequencer) Tail(ctx context.Context) (uint64, error) {
+	resp, err := r.c.Tail(ctx, &corfupb.TailRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Gsn, nil
+}
+
+// RemoteUnit is a StorageUnit served by another process. The caller owns
+// the connection.
+type RemoteUnit struct {
+	c corfupb.StorageUnitClient
+}
+
+func NewRemoteUnit(conn grpc.ClientConnInterface) *RemoteUnit {
+	return &RemoteUnit{c: corfupb.NewStorageUnitClient(conn)}
+}
+
+func (r *RemoteUnit) Write(ctx context.Context, gsn uint64, data []byte) error {
+	_, err := r.c.Write(ctx, &corfupb.WriteRequest{Gsn: gsn, Data: data})
+	return fromStatus(err)
+}
+
+func (r *RemoteUnit) Read(ctx context.Context, gsn uint64) ([]byte, error) {
+	resp, err := r.c.Read(ctx, &corfupb.ReadRequest{Gsn: gsn})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp.Data, nil
+}
+
+func (r *RemoteUnit) Fill(ctx context.Context, gsn uint64) error {
+	_, err := r.c.Fill(ctx, &corfupb.FillRequest{Gsn: gsn})
+	return fromStatus(err)
+}
+
+func (r *RemoteUnit) Trim(ctx context.Context, upTo uint64) error {
+	_, err := r.c.Trim(ctx, &corfupb.TrimRequest{UpTo: upTo})
+	return fromStatus(err)
+}
+
+func (r *RemoteUnit) Head(ctx context.Context) (uint64, error) {
+	resp, err := r.c.Head(ctx, &corfupb.HeadRequest{})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.Gsn, nil
+}
+
+func (r *RemoteUnit) Tail(ctx context.Context) (uint64, error) {
+	resp, err := r.c.Tail(ctx, &corfupb.TailRequest{})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.Gsn, nil
+}
@@ -0,0 +1,70 @@
+package corfu
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Sequencer hands out log positions. It only reserves GSNs; the records
+// themselves go straight from the client to the storage units.
+type Sequencer interface {
+	// Next reserves count consecutive GSNs and returns the first one.
+	Next(ctx context.Context, count int) (uint64, error)
+	// Tail returns the last GSN handed out, 0 if none.
+	Tail(ctx context.Context) (uint64, error)
+}
+
+var _ Sequencer = (*LocalSequencer)(nil)
+
+// LocalSequencer is an in-memory Sequencer. Its state is a single counter,
+// so after a restart it is rebuilt from the storage units with
+// RecoverSequencer.
+type LocalSequencer struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+// NewSequencer returns a sequencer whose first GSN is next (at least 1).
+func NewSequencer(next uint64) *LocalSequencer {
+	if next == 0 {
+		next = 1
+	}
+	return &LocalSequencer{next: next}
+}
+
+// RecoverSequencer returns a sequencer starting after the highest position
+// written or filled on any of units.
+func RecoverSequencer(ctx context.Context, units []StorageUnit) (*LocalSequencer, error) {
+	var max uint64
+	for _, u := range units {
+		tail, err := u.Tail(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if tail > max {
+			max = tail
+		}
+	}
+	return NewSequencer(max + 1), nil
+}
+
+func (s *LocalSequencer) Next(ctx context.Context, count int) (uint64, error) {
+	if count <= 0 {
+		return 0, errors.New("corfu: count must be positive")
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := s.next
+	s.next += uint64(count)
+	return first, nil
+}
+
+func (s *LocalSequencer) Tail(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next - 1, nil
+}
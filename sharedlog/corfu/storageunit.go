@@ -0,0 +1,146 @@
+package corfu
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Errors returned by StorageUnit; test with errors.Is.
+var (
+	// ErrWritten: the position already holds data or junk.
+	ErrWritten = errors.New("corfu: position already written")
+	// ErrUnwritten: nothing has been written to the position yet.
+	ErrUnwritten = errors.New("corfu: position not written")
+	// ErrJunk: the position was hole-filled and holds no record.
+	ErrJunk = errors.New("corfu: position filled with junk")
+	// ErrTrimmed: the position is below the unit's trim point.
+	ErrTrimmed = errors.New("corfu: position trimmed")
+)
+
+// StorageUnit stores the positions of one stripe of the log. Every position
+// is write-once: the first Write or Fill wins and later ones fail with
+// ErrWritten.
+type StorageUnit interface {
+	Write(ctx context.Context, gsn uint64, data []byte) error
+	Read(ctx context.Context, gsn uint64) ([]byte, error)
+	// Fill marks an unwritten position as junk so that readers can skip it.
+	// Filling a junk position is a no-op.
+	Fill(ctx context.Context, gsn uint64) error
+	// Trim discards every position below upTo.
+	Trim(ctx context.Context, upTo uint64) error
+	// Head returns the lowest untrimmed GSN.
+	Head(ctx context.Context) (uint64, error)
+	// Tail returns the highest written or filled GSN, 0 if none.
+	Tail(ctx context.Context) (uint64, error)
+}
+
+var _ StorageUnit = (*LocalUnit)(nil)
+
+type slot struct {
+	data []byte
+	junk bool
+}
+
+// LocalUnit is an in-memory StorageUnit.
+type LocalUnit struct {
+	mu    sync.RWMutex
+	slots map[uint64]slot
+	head  uint64
+	tail  uint64
+}
+
+func NewStorageUnit() *LocalUnit {
+	return &LocalUnit{
+		slots: make(map[uint64]slot),
+		head:  1,
+	}
+}
+
+func (u *LocalUnit) Write(ctx context.Context, gsn uint64, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return u.set(gsn, slot{data: data})
+}
+
+func (u *LocalUnit) Fill(ctx context.Context, gsn uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := u.set(gsn, slot{junk: true})
+	if errors.Is(err, ErrWritten) {
+		u.mu.RLock()
+		junk := u.slots[gsn].junk
+		u.mu.RUnlock()
+		if junk {
+			return nil
+		}
+	}
+	return err
+}
+
+func (u *LocalUnit) set(gsn uint64, s slot) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if gsn < u.head {
+		return ErrTrimmed
+	}
+	if _, ok := u.slots[gsn]; ok {
+		return ErrWritten
+	}
+	u.slots[gsn] = s
+	if gsn > u.tail {
+		u.tail = gsn
+	}
+	return nil
+}
+
+func (u *LocalUnit) Read(ctx context.Context, gsn uint64) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if gsn < u.head {
+		return nil, ErrTrimmed
+	}
+	s, ok := u.slots[gsn]
+	switch {
+	case !ok:
+		return nil, ErrUnwritten
+	case s.junk:
+		return nil, ErrJunk
+	}
+	return s.data, nil
+}
+
+func (u *LocalUnit) Trim(ctx context.Context, upTo uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if upTo <= u.head {
+		return nil
+	}
+	for gsn := range u.slots {
+		if gsn < upTo {
+			delete(u.slots, gsn)
+		}
+	}
+	u.head = upTo
+	return nil
+}
+
+func (u *LocalUnit) Head(ctx context.Context) (uint64, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.head, nil
+}
+
+func (u *LocalUnit) Tail(ctx context.Context) (uint64, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.tail, nil
+}
@@ -0,0 +1,204 @@
+package sharedlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// RecordType identifies what an envelope carries.
+type RecordType uint8
+
+const (
+	RecordTypeData   RecordType = 1
+	RecordTypeCommit RecordType = 2
+)
+
+func (t RecordType) String() string {
+	switch t {
+	case RecordTypeData:
+		return "DATA"
+	case RecordTypeCommit:
+		return "COMMIT"
+	default:
+		return fmt.Sprintf("RecordType(%d)", uint8(t))
+	}
+}
+
+// EnvelopeVersion is the format version written by EncodeData/EncodeCommit.
+const EnvelopeVersion uint8 = 1
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// envelope is the self-describing wrapper every backend stores in the log.
+// It is JSON so that it can travel through string-typed log APIs (Scalog).
+// Checksum covers Payload only.
+type envelope struct {
+	Version  uint8           `json:"v"`
+	Type     RecordType      `json:"t"`
+	TxnID    string          `json:"txn,omitempty"`
+	Token    string          `json:"tok,omitempty"`
+	Checksum uint32          `json:"crc"`
+	Payload  json.RawMessage `json:"p"`
+}
+
+// Sentinel causes carried by *DecodeError; test with errors.Is.
+var (
+	ErrMalformed          = errors.New("malformed envelope")
+	ErrUnsupportedVersion = errors.New("unsupported envelope version")
+	ErrChecksumMismatch   = errors.New("checksum mismatch")
+	ErrUnknownType        = errors.New("unknown record type")
+	ErrUnexpectedType     = errors.New("unexpected record type")
+)
+
+// DecodeError is returned when a log entry cannot be decoded.
+type DecodeError struct {
+	Kind error // one of the Err* sentinels above
+	Type RecordType
+	Err  error // underlying error, may be nil
+}
+
+func (e *DecodeError) Error() string {
+	msg := "sharedlog: decode: " + e.Kind.Error()
+	if e.Type != 0 {
+		msg += " (" + e.Type.String() + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *DecodeError) Is(target error) bool { return target == e.Kind }
+func (e *DecodeError) Unwrap() error        { return e.Err }
+
+// Record is a decoded envelope, or an input to SharedLog.AppendBatch.
+// Exactly one of Data / Commit is set, according to Type.
+type Record struct {
+	Type    RecordType
+	Version uint8
+	TxnID   string
+	Token   string
+	Data    *DataRecord
+	Commit  *CommitRecord
+}
+
+// EncodeRecord encodes a Record built for AppendBatch.
+func EncodeRecord(rec Record) ([]byte, error) {
+	switch {
+	case rec.Type == RecordTypeData && rec.Data != nil:
+		return EncodeData(*rec.Data)
+	case rec.Type == RecordTypeCommit && rec.Commit != nil:
+		return EncodeCommit(*rec.Commit)
+	default:
+		return nil, fmt.Errorf("sharedlog: cannot encode %v record without its payload", rec.Type)
+	}
+}
+
+// DataRecords wraps recs for AppendBatch.
+func DataRecords(recs []DataRecord) []Record {
+	res := make([]Record, len(recs))
+	for i := range recs {
+		res[i] = Record{Type: RecordTypeData, Data: &recs[i]}
+	}
+	return res
+}
+
+// CommitRecords wraps recs for AppendBatch.
+func CommitRecords(recs []CommitRecord) []Record {
+	res := make([]Record, len(recs))
+	for i := range recs {
+		res[i] = Record{Type: RecordTypeCommit, Commit: &recs[i]}
+	}
+	return res
+}
+
+// EncodeData wraps rec in a DATA envelope.
+func EncodeData(rec DataRecord) ([]byte, error) {
+	return encode(RecordTypeData, rec.TxnID, rec.Token, rec)
+}
+
+// EncodeCommit wraps rec in a COMMIT envelope.
+func EncodeCommit(rec CommitRecord) ([]byte, error) {
+	return encode(RecordTypeCommit, rec.TxnID, rec.Token, rec)
+}
+
+func encode(t RecordType, txnID, token string, v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Version:  EnvelopeVersion,
+		Type:     t,
+		TxnID:    txnID,
+		Token:    token,
+		Checksum: crc32.Checksum(payload, crcTable),
+		Payload:  payload,
+	})
+}
+
+// DecodeRecord parses and validates an envelope.
+func DecodeRecord(b []byte) (Record, error) {
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return Record{}, &DecodeError{Kind: ErrMalformed, Err: err}
+	}
+	if env.Version != EnvelopeVersion {
+		return Record{}, &DecodeError{Kind: ErrUnsupportedVersion, Type: env.Type,
+			Err: fmt.Errorf("version %d", env.Version)}
+	}
+	if len(env.Payload) == 0 {
+		return Record{}, &DecodeError{Kind: ErrMalformed, Type: env.Type, Err: errors.New("empty payload")}
+	}
+	if crc := crc32.Checksum(env.Payload, crcTable); crc != env.Checksum {
+		return Record{}, &DecodeError{Kind: ErrChecksumMismatch, Type: env.Type,
+			Err: fmt.Errorf("want %#x, got %#x", env.Checksum, crc)}
+	}
+
+	rec := Record{Type: env.Type, Version: env.Version, TxnID: env.TxnID, Token: env.Token}
+	switch env.Type {
+	case RecordTypeData:
+		var d DataRecord
+		if err := json.Unmarshal(env.Payload, &d); err != nil {
+			return Record{}, &DecodeError{Kind: ErrMalformed, Type: env.Type, Err: err}
+		}
+		d.TxnID, d.Token = env.TxnID, env.Token
+		rec.Data = &d
+	case RecordTypeCommit:
+		var c CommitRecord
+		if err := json.Unmarshal(env.Payload, &c); err != nil {
+			return Record{}, &DecodeError{Kind: ErrMalformed, Type: env.Type, Err: err}
+		}
+		c.TxnID, c.Token = env.TxnID, env.Token
+		rec.Commit = &c
+	default:
+		return Record{}, &DecodeError{Kind: ErrUnknownType, Type: env.Type}
+	}
+	return rec, nil
+}
+
+// DecodeData decodes b and requires it to be a DATA record.
+func DecodeData(b []byte) (DataRecord, error) {
+	rec, err := DecodeRecord(b)
+	if err != nil {
+		return DataRecord{}, err
+	}
+	if rec.Type != RecordTypeData {
+		return DataRecord{}, &DecodeError{Kind: ErrUnexpectedType, Type: rec.Type}
+	}
+	return *rec.Data, nil
+}
+
+// DecodeCommit decodes b and requires it to be a COMMIT record.
+func DecodeCommit(b []byte) (CommitRecord, error) {
+	rec, err := DecodeRecord(b)
+	if err != nil {
+		return CommitRecord{}, err
+	}
+	if rec.Type != RecordTypeCommit {
+		return CommitRecord{}, &DecodeError{Kind: ErrUnexpectedType, Type: rec.Type}
+	}
+	return *rec.Commit, nil
+}
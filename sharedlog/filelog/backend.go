@@ -0,0 +1,29 @@
+package filelog
+
+import "github.com/chn0318/logstore/sharedlog"
+
+// The "file" backend reads filelog-dir, filelog-sync, filelog-segment-bytes
+// and filelog-sync-interval.
+func init() {
+	sharedlog.RegisterBackend("file", func(cfg sharedlog.Config, _ sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		opts := DefaultOptions()
+		if cfg.IsSet("filelog-sync") {
+			policy, err := ParseSyncPolicy(cfg.GetString("filelog-sync"))
+			if err != nil {
+				return nil, err
+			}
+			opts.Sync = policy
+		}
+		if cfg.IsSet("filelog-segment-bytes") {
+			opts.SegmentSize = cfg.GetInt64("filelog-segment-bytes")
+		}
+		if cfg.IsSet("filelog-sync-interval") {
+			opts.SyncInterval = cfg.GetDuration("filelog-sync-interval")
+		}
+		dir := "logstore-data"
+		if cfg.IsSet("filelog-dir") {
+			dir = cfg.GetString("filelog-dir")
+		}
+		return NewFileLog(dir, opts)
+	})
+}
@@ -0,0 +1,538 @@
+package filelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+var (
+	_ sharedlog.SharedLog     = (*FileLog)(nil)
+	_ sharedlog.RecordScanner = (*FileLog)(nil)
+)
+
+// SyncPolicy decides when appended records are fsynced.
+type SyncPolicy int
+
+const (
+	// SyncEveryAppend fsyncs before every append returns.
+	SyncEveryAppend SyncPolicy = iota
+	// SyncGroup makes every append durable before it returns, but lets
+	// concurrent appends share one fsync.
+	SyncGroup
+	// SyncInterval fsyncs in the background every Options.SyncInterval;
+	// appends return before they are durable.
+	SyncInterval
+)
+
+// ParseSyncPolicy maps "append", "group" or "interval" to a SyncPolicy.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "append":
+		return SyncEveryAppend, nil
+	case "group":
+		return SyncGroup, nil
+	case "interval":
+		return SyncInterval, nil
+	default:
+		return 0, fmt.Errorf("filelog: unknown sync policy %q", s)
+	}
+}
+
+type Options struct {
+	// SegmentSize is the size in bytes after which a new segment is started.
+	SegmentSize int64
+	Sync        SyncPolicy
+	// SyncInterval is only used with the SyncInterval policy.
+	SyncInterval time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		SegmentSize:  64 << 20,
+		Sync:         SyncGroup,
+		SyncInterval: 10 * time.Millisecond,
+	}
+}
+
+var ErrClosed = errors.New("filelog: closed")
+
+// FileLog is a durable single-node SharedLog made of segmented append-only
+// files in one directory. GSNs start at 1 and are dense.
+type FileLog struct {
+	dir  string
+	opts Options
+
+	// mu guards segments and the append path. Reads only need RLock because
+	// segments are never rewritten in place.
+	mu       sync.RWMutex
+	segments []*segment // sorted by base; the last one is active
+	closed   bool
+
+	// written counts append calls; synced is the largest count known durable.
+	// Both only grow, which is what lets concurrent appenders share fsyncs.
+	seqMu   sync.Mutex
+	written uint64
+	synced  uint64
+	// fsyncMu serializes fsyncs so that a waiter arriving mid-fsync
+	// re-checks synced instead of issuing its own.
+	fsyncMu sync.Mutex
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+// NewFileLog opens (or creates) the log in dir and recovers it: the newest
+// segment is scanned and truncated at the first torn frame, older segments
+// are trusted if their index is consistent.
+func NewFileLog(dir string, opts Options) (*FileLog, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultOptions().SegmentSize
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = DefaultOptions().SyncInterval
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	bases, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &FileLog{dir: dir, opts: opts}
+	for i, base := range bases {
+		last := i == len(bases)-1
+		seg, err := openSegment(dir, base, last)
+		if err != nil {
+			l.closeSegments()
+			return nil, fmt.Errorf("filelog: open segment %d: %w", base, err)
+		}
+		if n := len(l.segments); n > 0 && l.segments[n-1].next() != base {
+			seg.close()
+			l.closeSegments()
+			return nil, fmt.Errorf("filelog: gap before segment %d", base)
+		}
+		l.segments = append(l.segments, seg)
+	}
+	if len(l.segments) == 0 {
+		seg, err := createSegment(dir, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := syncDir(dir); err != nil {
+			seg.close()
+			return nil, err
+		}
+		l.segments = append(l.segments, seg)
+	}
+
+	if opts.Sync == SyncInterval {
+		l.stopC = make(chan struct{})
+		l.doneC = make(chan struct{})
+		go l.syncLoop()
+	}
+	return l, nil
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bases := make([]uint64, 0)
+	for _, e := range ents {
+		name := e.Name()
+		if !strings.HasSuffix(name, logSuffix) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, logSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+func (l *FileLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	data, err := sharedlog.EncodeData(rec)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	gsn, err := l.append(ctx, data)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	return sharedlog.RecordRef{GSN: gsn}, nil
+}
+
+func (l *FileLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	data, err := sharedlog.EncodeCommit(rec)
+	if err != nil {
+		return 0, err
+	}
+	return l.append(ctx, data)
+}
+
+func (l *FileLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch writes all records with contiguous GSNs (possibly across a
+// segment rollover) and makes them durable with a single fsync.
+func (l *FileLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	payloads := make([][]byte, len(recs))
+	for i, rec := range recs {
+		data, err := sharedlog.EncodeRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = data
+	}
+	gsns, err := l.appendMany(ctx, payloads)
+	if gsns == nil {
+		return nil, err
+	}
+	// 写到一半失败：前面的记录已经落盘，只有后面的算失败
+	refs := make([]sharedlog.RecordRef, len(recs))
+	errs := make([]error, len(recs))
+	for i := range recs {
+		if i < len(gsns) {
+			refs[i] = sharedlog.RecordRef{GSN: gsns[i]}
+		} else {
+			errs[i] = err
+		}
+	}
+	return sharedlog.BatchResult(refs, errs)
+}
+
+func (l *FileLog) append(ctx context.Context, payload []byte) (uint64, error) {
+	gsns, err := l.appendMany(ctx, [][]byte{payload})
+	if err != nil {
+		return 0, err
+	}
+	return gsns[0], nil
+}
+
+// appendMany checks ctx only before writing: once the frames are in the
+// segment they will be fsynced and become visible to readers anyway. If a
+// frame cannot be written, the ones before it stay in the log: appendMany
+// makes them durable and returns their GSNs along with the error.
+func (l *FileLog) appendMany(ctx context.Context, payloads [][]byte) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, ErrClosed
+	}
+	gsns := make([]uint64, 0, len(payloads))
+	active := l.segments[len(l.segments)-1]
+	var err error
+	for _, payload := range payloads {
+		if active.size > 0 && active.size+frameHeaderSize+int64(len(payload)) > l.opts.SegmentSize {
+			var next *segment
+			if next, err = l.roll(active); err != nil {
+				break
+			}
+			active = next
+		}
+		gsn := active.next()
+		if err = active.append(gsn, payload); err != nil {
+			break
+		}
+		gsns = append(gsns, gsn)
+	}
+	if len(gsns) == 0 {
+		l.mu.Unlock()
+		return nil, err
+	}
+	l.seqMu.Lock()
+	l.written++
+	seq := l.written
+	l.seqMu.Unlock()
+	l.mu.Unlock()
+
+	switch l.opts.Sync {
+	case SyncEveryAppend, SyncGroup:
+		if err := l.waitDurable(seq); err != nil {
+			return nil, err
+		}
+	}
+	return gsns, err
+}
+
+// roll seals active and starts a new segment. The sealed segment is fsynced
+// first, so everything written so far is durable once roll returns. Caller
+// holds l.mu.
+func (l *FileLog) roll(active *segment) (*segment, error) {
+	if err := active.sync(); err != nil {
+		return nil, err
+	}
+	l.seqMu.Lock()
+	l.synced = l.written
+	l.seqMu.Unlock()
+
+	seg, err := createSegment(l.dir, active.next())
+	if err != nil {
+		return nil, err
+	}
+	if err := syncDir(l.dir); err != nil {
+		seg.close()
+		return nil, err
+	}
+	l.segments = append(l.segments, seg)
+	return seg, nil
+}
+
+// waitDurable returns once append number seq has been fsynced. Under
+// SyncGroup whichever appender gets fsyncMu first fsyncs on behalf of all
+// appends written before it started; the rest find their seq covered.
+func (l *FileLog) waitDurable(seq uint64) error {
+	if l.opts.Sync == SyncGroup && l.isSynced(seq) {
+		return nil
+	}
+	return l.Sync()
+}
+
+func (l *FileLog) isSynced(seq uint64) bool {
+	l.seqMu.Lock()
+	defer l.seqMu.Unlock()
+	return l.synced >= seq
+}
+
+// Sync fsyncs the active segment, making every append that has returned so
+// far durable.
+func (l *FileLog) Sync() error {
+	l.mu.RLock()
+	if l.closed {
+		l.mu.RUnlock()
+		return ErrClosed
+	}
+	active := l.segments[len(l.segments)-1]
+	l.seqMu.Lock()
+	seq := l.written
+	l.seqMu.Unlock()
+	l.mu.RUnlock()
+
+	l.fsyncMu.Lock()
+	defer l.fsyncMu.Unlock()
+	if l.isSynced(seq) {
+		return nil
+	}
+	if err := active.sync(); err != nil {
+		return err
+	}
+	l.seqMu.Lock()
+	if seq > l.synced {
+		l.synced = seq
+	}
+	l.seqMu.Unlock()
+	return nil
+}
+
+func (l *FileLog) syncLoop() {
+	defer close(l.doneC)
+	ticker := time.NewTicker(l.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Sync(); err != nil && !errors.Is(err, ErrClosed) {
+				log.Printf("filelog: background sync: %v", err)
+			}
+		case <-l.stopC:
+			return
+		}
+	}
+}
+
+func (l *FileLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return sharedlog.DataRecord{}, err
+	}
+	data, err := l.read(ref.GSN)
+	if err != nil {
+		return sharedlog.DataRecord{}, err
+	}
+	return sharedlog.DecodeData(data)
+}
+
+func (l *FileLog) read(gsn uint64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	seg := l.segmentFor(gsn)
+	if seg == nil {
+		return nil, fmt.Errorf("data record not found: gsn=%d", gsn)
+	}
+	return seg.read(gsn)
+}
+
+// segmentFor returns the segment holding gsn, or nil. Caller holds l.mu.
+func (l *FileLog) segmentFor(gsn uint64) *segment {
+	i := sort.Search(len(l.segments), func(i int) bool { return l.segments[i].base > gsn }) - 1
+	if i < 0 || gsn >= l.segments[i].next() {
+		return nil
+	}
+	return l.segments[i]
+}
+
+func (l *FileLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	if head := l.Head(ctx); from < head {
+		from = head
+	}
+	if tail := l.Tail(ctx); to > tail {
+		to = tail
+	}
+	for gsn := from; gsn <= to; gsn++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := l.read(gsn)
+		if err != nil {
+			return err
+		}
+		rec, err := sharedlog.DecodeCommit(data)
+		if errors.Is(err, sharedlog.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := handler(gsn, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *FileLog) ScanRecords(ctx context.Context, from, to uint64, fn func(sharedlog.RecordRef, sharedlog.Record) error) error {
+	if head := l.Head(ctx); from < head {
+		from = head
+	}
+	if tail := l.Tail(ctx); to > tail {
+		to = tail
+	}
+	for gsn := from; gsn <= to; gsn++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := l.read(gsn)
+		if err != nil {
+			return err
+		}
+		rec, err := sharedlog.DecodeRecord(data)
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := fn(sharedlog.RecordRef{GSN: gsn}, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe polls the log; appends do not signal subscribers.
+func (l *FileLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	return sharedlog.PollCommits(ctx, l, from, sharedlog.DefaultPollInterval, handler)
+}
+
+func (l *FileLog) Head(ctx context.Context) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[0].base
+}
+
+func (l *FileLog) Tail(ctx context.Context) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[len(l.segments)-1].next() - 1
+}
+
+// Trim deletes every sealed segment that lies entirely below upTo. The
+// active segment is never removed, so Head() may stay below upTo.
+func (l *FileLog) Trim(ctx context.Context, upTo uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	n := 0
+	for n < len(l.segments)-1 && l.segments[n].next() <= upTo {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	// Remove oldest first so that a crash midway leaves a contiguous log.
+	// A segment that could not be removed is kept; the next Trim retries it.
+	for i := 0; i < n; i++ {
+		if err := l.segments[i].remove(l.dir); err != nil {
+			l.segments = l.segments[i:]
+			return err
+		}
+	}
+	l.segments = l.segments[n:]
+	return syncDir(l.dir)
+}
+
+// Close syncs and closes every segment. Further calls return ErrClosed.
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrClosed
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	if l.stopC != nil {
+		close(l.stopC)
+		<-l.doneC
+	}
+	l.fsyncMu.Lock()
+	defer l.fsyncMu.Unlock()
+	err := l.segments[len(l.segments)-1].sync()
+	if e := l.closeSegments(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (l *FileLog) closeSegments() error {
+	var err error
+	for _, seg := range l.segments {
+		if e := seg.close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
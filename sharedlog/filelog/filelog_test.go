@@ -0,0 +1,215 @@
+package filelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+func openLog(t *testing.T, dir string, opts Options) *FileLog {
+	t.Helper()
+	l, err := NewFileLog(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func appendValues(t *testing.T, l *FileLog, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		ref, err := l.AppendData(context.Background(), sharedlog.DataRecord{Key: "k", Value: []byte(fmt.Sprint(i))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ref.GSN != uint64(i) {
+			t.Fatalf("record %d appended at GSN %d", i, ref.GSN)
+		}
+	}
+}
+
+// checkValues asserts that GSNs from..to hold the values appendValues wrote.
+func checkValues(t *testing.T, l *FileLog, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		rec, err := l.ReadData(context.Background(), sharedlog.RecordRef{GSN: uint64(i)})
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if string(rec.Value) != fmt.Sprint(i) {
+			t.Fatalf("GSN %d holds %q", i, rec.Value)
+		}
+	}
+}
+
+// lastSegment returns the log file of the newest segment in dir.
+func lastSegment(t *testing.T, dir string) string {
+	t.Helper()
+	bases, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return segmentName(dir, bases[len(bases)-1], logSuffix)
+}
+
+func TestReopenAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	l := openLog(t, dir, DefaultOptions())
+	appendValues(t, l, 1, 5)
+
+	// l is never closed, as if the process had died
+	reopened := openLog(t, dir, DefaultOptions())
+	if head, tail := reopened.Head(ctx), reopened.Tail(ctx); head != 1 || tail != 5 {
+		t.Fatalf("head, tail = %d, %d, want 1, 5", head, tail)
+	}
+	checkValues(t, reopened, 1, 5)
+	appendValues(t, reopened, 6, 7)
+}
+
+func TestTornTail(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		damage func(f *os.File, size int64) error
+		// tail is what survives: a damaged last record is dropped, garbage
+		// after it is cut off
+		tail uint64
+	}{
+		{"truncated", func(f *os.File, size int64) error { return f.Truncate(size - 3) }, 2},
+		{"corrupt", func(f *os.File, size int64) error {
+			_, err := f.WriteAt([]byte{0xff}, size-1)
+			return err
+		}, 2},
+		{"half header", func(f *os.File, size int64) error {
+			_, err := f.WriteAt(make([]byte, frameHeaderSize/2), size)
+			return err
+		}, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			l := openLog(t, dir, DefaultOptions())
+			appendValues(t, l, 1, 3)
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.OpenFile(lastSegment(t, dir), os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := f.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.damage(f, st.Size()); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			l = openLog(t, dir, DefaultOptions())
+			want := tc.tail
+			if tail := l.Tail(ctx); tail != want {
+				t.Fatalf("tail = %d, want %d", tail, want)
+			}
+			checkValues(t, l, 1, int(want))
+			if _, err := l.ReadData(ctx, sharedlog.RecordRef{GSN: want + 1}); err == nil {
+				t.Fatalf("read of the dropped GSN %d succeeded", want+1)
+			}
+			// the damaged bytes are gone, so the next append is readable
+			appendValues(t, l, int(want)+1, int(want)+1)
+			checkValues(t, l, 1, int(want)+1)
+		})
+	}
+}
+
+func TestSegmentRollover(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	// a few records per segment
+	opts.SegmentSize = 2 * (frameHeaderSize + 40)
+	l := openLog(t, dir, opts)
+	appendValues(t, l, 1, 9)
+	bases, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bases) < 3 {
+		t.Fatalf("%d segments after 9 appends, want several", len(bases))
+	}
+	checkValues(t, l, 1, 9)
+
+	if err := l.Trim(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	head := l.Head(ctx)
+	if head <= 1 || head > 5 {
+		t.Fatalf("head after Trim(5) = %d", head)
+	}
+	checkValues(t, l, int(head), 9)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l = openLog(t, dir, opts)
+	if h, tail := l.Head(ctx), l.Tail(ctx); h != head || tail != 9 {
+		t.Fatalf("after reopen head, tail = %d, %d, want %d, 9", h, tail, head)
+	}
+	checkValues(t, l, int(head), 9)
+	appendValues(t, l, 10, 12)
+}
+
+func TestAppendBatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	// one record per segment, so the second record of a batch rolls
+	opts.SegmentSize = 1
+	l := openLog(t, dir, opts)
+	// a directory where the next segment goes makes the roll fail
+	blocker := segmentName(dir, 2, logSuffix)
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := sharedlog.DataRecords([]sharedlog.DataRecord{{Key: "a"}, {Key: "b"}, {Key: "c"}})
+	refs, err := l.AppendBatch(ctx, recs)
+	var be *sharedlog.BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want a BatchError", err)
+	}
+	errs := sharedlog.RecordErrors(len(recs), err)
+	if errs[0] != nil || errs[1] == nil || errs[2] == nil {
+		t.Fatalf("per-record errors = %v, want all but the first to fail", errs)
+	}
+	if refs[0].GSN != 1 {
+		t.Fatalf("first record at GSN %d, want 1", refs[0].GSN)
+	}
+	if tail := l.Tail(ctx); tail != 1 {
+		t.Fatalf("tail = %d, want only the first record", tail)
+	}
+
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := l.AppendData(ctx, sharedlog.DataRecord{Key: "b"})
+	if err != nil || ref.GSN != 2 {
+		t.Fatalf("append after the failure = %d, %v, want GSN 2", ref.GSN, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l = openLog(t, dir, opts)
+	for gsn, key := range map[uint64]string{1: "a", 2: "b"} {
+		rec, err := l.ReadData(ctx, sharedlog.RecordRef{GSN: gsn})
+		if err != nil || rec.Key != key {
+			t.Fatalf("GSN %d after reopen = %v, %v, want %s", gsn, rec, err, key)
+		}
+	}
+}
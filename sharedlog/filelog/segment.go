@@ -0,0 +1,265 @@
+package filelog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Each log entry is framed as
+//
+//	len(4) | crc32c(gsn ++ payload)(4) | gsn(8) | payload(len)
+//
+// and each index entry is the 8-byte file offset of the frame. GSNs are dense
+// inside a segment, so index entry i belongs to GSN base+i.
+const (
+	frameHeaderSize = 16
+	indexEntrySize  = 8
+
+	logSuffix   = ".log"
+	indexSuffix = ".idx"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errTornFrame = errors.New("filelog: torn or corrupt frame")
+
+// segment is one log file plus its GSN->offset index. base is the GSN of the
+// first entry; offsets[i] is where GSN base+i starts.
+type segment struct {
+	base    uint64
+	log     *os.File
+	idx     *os.File
+	size    int64
+	offsets []int64
+}
+
+func segmentName(dir string, base uint64, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", base, suffix))
+}
+
+func createSegment(dir string, base uint64) (*segment, error) {
+	lf, err := os.OpenFile(segmentName(dir, base, logSuffix), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	xf, err := os.OpenFile(segmentName(dir, base, indexSuffix), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		lf.Close()
+		return nil, err
+	}
+	return &segment{base: base, log: lf, idx: xf}, nil
+}
+
+// openSegment opens an existing segment. If verify is set the log file is
+// scanned frame by frame, any torn tail is truncated and the index is
+// rebuilt; otherwise the index is trusted when its size is consistent.
+func openSegment(dir string, base uint64, verify bool) (*segment, error) {
+	lf, err := os.OpenFile(segmentName(dir, base, logSuffix), os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	xf, err := os.OpenFile(segmentName(dir, base, indexSuffix), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		lf.Close()
+		return nil, err
+	}
+	s := &segment{base: base, log: lf, idx: xf}
+
+	st, err := lf.Stat()
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	s.size = st.Size()
+
+	if !verify {
+		if ok, err := s.loadIndex(); err != nil {
+			s.close()
+			return nil, err
+		} else if ok {
+			return s, nil
+		}
+	}
+	if err := s.recover(); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadIndex reads the index file. It reports false if the index does not
+// line up with the log file and must be rebuilt.
+func (s *segment) loadIndex() (bool, error) {
+	buf, err := io.ReadAll(io.NewSectionReader(s.idx, 0, 1<<62))
+	if err != nil {
+		return false, err
+	}
+	if len(buf)%indexEntrySize != 0 {
+		return false, nil
+	}
+	offsets := make([]int64, len(buf)/indexEntrySize)
+	for i := range offsets {
+		offsets[i] = int64(binary.BigEndian.Uint64(buf[i*indexEntrySize:]))
+	}
+	if len(offsets) == 0 {
+		if s.size != 0 {
+			return false, nil
+		}
+		s.offsets = offsets
+		return true, nil
+	}
+	// The last indexed frame must end exactly at the end of the file.
+	last := offsets[len(offsets)-1]
+	n, err := s.frameLen(last)
+	if err != nil || last+n != s.size {
+		return false, nil
+	}
+	s.offsets = offsets
+	return true, nil
+}
+
+// recover scans every frame, truncates the log at the first torn or corrupt
+// frame, and rewrites the index from what survived.
+func (s *segment) recover() error {
+	offsets := make([]int64, 0)
+	var off int64
+	for off < s.size {
+		n, err := s.frameLen(off)
+		if err != nil {
+			break
+		}
+		if _, err := s.readFrame(off, s.base+uint64(len(offsets))); err != nil {
+			break
+		}
+		offsets = append(offsets, off)
+		off += n
+	}
+	if off != s.size {
+		if err := s.log.Truncate(off); err != nil {
+			return err
+		}
+		if err := s.log.Sync(); err != nil {
+			return err
+		}
+		s.size = off
+	}
+
+	buf := make([]byte, len(offsets)*indexEntrySize)
+	for i, o := range offsets {
+		binary.BigEndian.PutUint64(buf[i*indexEntrySize:], uint64(o))
+	}
+	if err := s.idx.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.idx.WriteAt(buf, 0); err != nil {
+		return err
+	}
+	if err := s.idx.Sync(); err != nil {
+		return err
+	}
+	s.offsets = offsets
+	return nil
+}
+
+// frameLen returns the total on-disk size of the frame at off.
+func (s *segment) frameLen(off int64) (int64, error) {
+	var hdr [frameHeaderSize]byte
+	if off+frameHeaderSize > s.size {
+		return 0, errTornFrame
+	}
+	if _, err := s.log.ReadAt(hdr[:], off); err != nil {
+		return 0, err
+	}
+	n := frameHeaderSize + int64(binary.BigEndian.Uint32(hdr[0:4]))
+	if off+n > s.size {
+		return 0, errTornFrame
+	}
+	return n, nil
+}
+
+// readFrame reads and validates the frame at off, which must hold gsn.
+func (s *segment) readFrame(off int64, gsn uint64) ([]byte, error) {
+	var hdr [frameHeaderSize]byte
+	if _, err := s.log.ReadAt(hdr[:], off); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[0:4])
+	crc := binary.BigEndian.Uint32(hdr[4:8])
+	if got := binary.BigEndian.Uint64(hdr[8:16]); got != gsn {
+		return nil, fmt.Errorf("%w: gsn=%d found %d", errTornFrame, gsn, got)
+	}
+	payload := make([]byte, n)
+	if _, err := s.log.ReadAt(payload, off+frameHeaderSize); err != nil {
+		return nil, err
+	}
+	if frameChecksum(gsn, payload) != crc {
+		return nil, fmt.Errorf("%w: gsn=%d checksum mismatch", errTornFrame, gsn)
+	}
+	return payload, nil
+}
+
+// append writes one frame and its index entry. It does not fsync.
+func (s *segment) append(gsn uint64, payload []byte) error {
+	buf := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], frameChecksum(gsn, payload))
+	binary.BigEndian.PutUint64(buf[8:16], gsn)
+	copy(buf[frameHeaderSize:], payload)
+	if _, err := s.log.WriteAt(buf, s.size); err != nil {
+		return err
+	}
+
+	var ib [indexEntrySize]byte
+	binary.BigEndian.PutUint64(ib[:], uint64(s.size))
+	if _, err := s.idx.WriteAt(ib[:], int64(len(s.offsets))*indexEntrySize); err != nil {
+		return err
+	}
+	s.offsets = append(s.offsets, s.size)
+	s.size += int64(len(buf))
+	return nil
+}
+
+func (s *segment) read(gsn uint64) ([]byte, error) {
+	return s.readFrame(s.offsets[gsn-s.base], gsn)
+}
+
+// next returns the GSN the next append to this segment would get.
+func (s *segment) next() uint64 { return s.base + uint64(len(s.offsets)) }
+
+func (s *segment) sync() error {
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+	return s.idx.Sync()
+}
+
+func (s *segment) close() error {
+	err := s.log.Close()
+	if e := s.idx.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// remove deletes the segment's files and then closes it. If that fails the
+// segment stays open and readable, and remove can be called again.
+func (s *segment) remove(dir string) error {
+	for _, suffix := range []string{logSuffix, indexSuffix} {
+		if err := os.Remove(segmentName(dir, s.base, suffix)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	s.close()
+	return nil
+}
+
+func frameChecksum(gsn uint64, payload []byte) uint32 {
+	var g [8]byte
+	binary.BigEndian.PutUint64(g[:], gsn)
+	return crc32.Update(crc32.Checksum(g[:], crcTable), crcTable, payload)
+}
@@ -0,0 +1,10 @@
+package memorylog
+
+import "github.com/chn0318/logstore/sharedlog"
+
+// The "memory" backend keeps the log in process memory; it is lost on exit.
+func init() {
+	sharedlog.RegisterBackend("memory", func(sharedlog.Config, sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		return NewMemoryLog(), nil
+	})
+}
@@ -1,63 +1,142 @@
 package memorylog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/chn0318/logstore/sharedlog"
 )
 
+var (
+	_ sharedlog.SharedLog     = (*MemoryLog)(nil)
+	_ sharedlog.RecordScanner = (*MemoryLog)(nil)
+)
+
+// MemoryLog keeps encoded envelopes in memory, so it exercises the same
+// encode/decode path as the durable backends.
 type MemoryLog struct {
-	dataRecs   map[uint64]sharedlog.DataRecord
-	commitRecs map[uint64]sharedlog.CommitRecord
-	tail       uint64
-	mu         sync.RWMutex
+	recs map[uint64][]byte
+	head uint64
+	tail uint64
+	mu   sync.RWMutex
+	// appended is closed and replaced on every append to wake subscribers
+	appended chan struct{}
 }
 
 func NewMemoryLog() *MemoryLog {
 	return &MemoryLog{
-		dataRecs:   make(map[uint64]sharedlog.DataRecord),
-		commitRecs: make(map[uint64]sharedlog.CommitRecord),
+		recs:     make(map[uint64][]byte),
+		head:     1,
+		appended: make(chan struct{}),
 	}
 }
 
-func (l *MemoryLog) AppendData(rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+func (l *MemoryLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	if err := ctx.Err(); err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	data, err := sharedlog.EncodeData(rec)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.tail++
-	l.dataRecs[l.tail] = rec
+	l.recs[l.tail] = data
+	l.wake()
 
 	return sharedlog.RecordRef{
 		GSN: l.tail,
 	}, nil
 }
 
-func (l *MemoryLog) AppendCommit(rec sharedlog.CommitRecord) (uint64, error) {
+func (l *MemoryLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	data, err := sharedlog.EncodeCommit(rec)
+	if err != nil {
+		return 0, err
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.tail++
-	l.commitRecs[l.tail] = rec
+	l.recs[l.tail] = data
+	l.wake()
 	return l.tail, nil
 }
 
-func (l *MemoryLog) ReadData(ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+func (l *MemoryLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch appends all records atomically with contiguous GSNs.
+func (l *MemoryLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	encoded := make([][]byte, len(recs))
+	for i, rec := range recs {
+		data, err := sharedlog.EncodeRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	refs := make([]sharedlog.RecordRef, len(encoded))
+	for i, data := range encoded {
+		l.tail++
+		l.recs[l.tail] = data
+		refs[i] = sharedlog.RecordRef{GSN: l.tail}
+	}
+	l.wake()
+	return refs, nil
+}
+
+// caller holds l.mu
+func (l *MemoryLog) wake() {
+	close(l.appended)
+	l.appended = make(chan struct{})
+}
+
+func (l *MemoryLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return sharedlog.DataRecord{}, err
+	}
 	l.mu.RLock()
-	defer l.mu.RUnlock()
-	rec, ok := l.dataRecs[ref.GSN]
+	data, ok := l.recs[ref.GSN]
+	l.mu.RUnlock()
 	if !ok {
 		return sharedlog.DataRecord{}, fmt.Errorf("data record not found: gsn=%d", ref.GSN)
 	}
-	return rec, nil
+	return sharedlog.DecodeData(data)
 }
 
-func (l *MemoryLog) ReplayCommits(from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+func (l *MemoryLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	for gsn := from; gsn <= to; gsn++ {
-		rec, ok := l.commitRecs[gsn]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, ok := l.recs[gsn]
 		if !ok {
 			continue
 		}
+		rec, err := sharedlog.DecodeCommit(data)
+		if errors.Is(err, sharedlog.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
 		if err := handler(gsn, rec); err != nil {
 			return err
 		}
@@ -65,5 +144,108 @@ func (l *MemoryLog) ReplayCommits(from, to uint64, handler func(uint64, sharedlo
 	return nil
 }
 
-func (l *MemoryLog) Head() uint64 { return 1 }
-func (l *MemoryLog) Tail() uint64 { l.mu.RLock(); defer l.mu.RUnlock(); return l.tail }
+func (l *MemoryLog) ScanRecords(ctx context.Context, from, to uint64, fn func(sharedlog.RecordRef, sharedlog.Record) error) error {
+	l.mu.RLock()
+	if from < l.head {
+		from = l.head
+	}
+	if to > l.tail {
+		to = l.tail
+	}
+	var batch [][]byte
+	for gsn := from; gsn <= to; gsn++ {
+		batch = append(batch, l.recs[gsn])
+	}
+	l.mu.RUnlock()
+
+	for i, data := range batch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		gsn := from + uint64(i)
+		rec, err := sharedlog.DecodeRecord(data)
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := fn(sharedlog.RecordRef{GSN: gsn}, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe wakes on every append. The handler runs without l.mu held, so it
+// may read from the log.
+func (l *MemoryLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	next := from
+	for {
+		l.mu.RLock()
+		if next == 0 {
+			next = l.head
+		}
+		head, tail, wait := l.head, l.tail, l.appended
+		var batch [][]byte
+		if next >= head {
+			for gsn := next; gsn <= tail; gsn++ {
+				batch = append(batch, l.recs[gsn])
+			}
+		}
+		l.mu.RUnlock()
+
+		if next < head {
+			return fmt.Errorf("%w: gsn %d is below head %d", sharedlog.ErrTrimmed, next, head)
+		}
+		for _, data := range batch {
+			rec, err := sharedlog.DecodeCommit(data)
+			if errors.Is(err, sharedlog.ErrUnexpectedType) {
+				next++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("gsn=%d: %w", next, err)
+			}
+			if err := handler(next, rec); err != nil {
+				return err
+			}
+			next++
+		}
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *MemoryLog) Head(ctx context.Context) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.head
+}
+
+func (l *MemoryLog) Tail(ctx context.Context) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tail
+}
+
+func (l *MemoryLog) Trim(ctx context.Context, upTo uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if upTo > l.tail+1 {
+		upTo = l.tail + 1
+	}
+	for gsn := l.head; gsn < upTo; gsn++ {
+		delete(l.recs, gsn)
+	}
+	if upTo > l.head {
+		l.head = upTo
+	}
+	return nil
+}
+
+// Close is a no-op; the records stay readable.
+func (l *MemoryLog) Close() error { return nil }
@@ -0,0 +1,46 @@
+package raftlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// The "raft" backend starts this server's replica of a raft-replicated log
+// in raft-dir (default logstore-raft). raft-peers lists every replica as "id=host:port" (the raft
+// address), the same on all servers; raft-id names this one and raft-bind
+// defaults to its address. Hooks.OnLeader and Hooks.Retain become
+// Options.OnLeader and Options.Retain.
+func init() {
+	sharedlog.RegisterBackend("raft", func(cfg sharedlog.Config, hooks sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		opts := Options{ID: cfg.GetString("raft-id"), Retain: hooks.Retain}
+		if hooks.OnLeader != nil {
+			opts.OnLeader = func(ctx context.Context, l *RaftLog) error {
+				return hooks.OnLeader(ctx, l)
+			}
+		}
+		for _, p := range cfg.GetStringSlice("raft-peers") {
+			id, addr, ok := strings.Cut(p, "=")
+			if !ok {
+				return nil, fmt.Errorf("bad raft-peers entry %q, want id=host:port", p)
+			}
+			opts.Peers = append(opts.Peers, Peer{ID: id, Addr: addr})
+			if id == opts.ID {
+				opts.BindAddr = addr
+			}
+		}
+		if cfg.IsSet("raft-bind") {
+			opts.BindAddr = cfg.GetString("raft-bind")
+		}
+		if opts.ID == "" || opts.BindAddr == "" {
+			return nil, fmt.Errorf("raft backend needs raft-id and a raft-peers entry (or raft-bind) for it")
+		}
+		dir := "logstore-raft"
+		if cfg.IsSet("raft-dir") {
+			dir = cfg.GetString("raft-dir")
+		}
+		return NewRaftLog(dir, opts)
+	})
+}
@@ -0,0 +1,239 @@
+package raftlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/raft"
+)
+
+// Raft commands. Every replica applies the same commands in the same order,
+// so they all assign the same GSNs.
+const (
+	opAppend byte = 1 // uvarint count, then count × (uvarint len, payload)
+	opTrim   byte = 2 // uvarint upTo
+)
+
+func encodeAppend(payloads [][]byte) []byte {
+	n := 1 + binary.MaxVarintLen64
+	for _, p := range payloads {
+		n += binary.MaxVarintLen64 + len(p)
+	}
+	buf := make([]byte, 0, n)
+	buf = append(buf, opAppend)
+	buf = binary.AppendUvarint(buf, uint64(len(payloads)))
+	for _, p := range payloads {
+		buf = binary.AppendUvarint(buf, uint64(len(p)))
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+func encodeTrim(upTo uint64) []byte {
+	return binary.AppendUvarint([]byte{opTrim}, upTo)
+}
+
+// applyResult is what fsm.Apply returns to the leader's ApplyFuture.
+type applyResult struct {
+	first uint64 // GSN of the first appended record
+	err   error
+}
+
+var errBadCommand = errors.New("raftlog: malformed command")
+
+// fsm holds the replicated log: encoded envelopes by GSN, GSNs starting at 1.
+type fsm struct {
+	mu   sync.RWMutex
+	recs map[uint64][]byte
+	head uint64
+	tail uint64
+	// trimTo 是 leader 要求裁到的位置；本副本只裁到 retain() 为止，剩下的等 retain 前进
+	trimTo uint64
+	retain func() uint64
+}
+
+func newFSM(retain func() uint64) *fsm {
+	return &fsm{recs: make(map[uint64][]byte), head: 1, trimTo: 1, retain: retain}
+}
+
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	if l.Type != raft.LogCommand || len(l.Data) == 0 {
+		return applyResult{err: errBadCommand}
+	}
+	data := l.Data[1:]
+	switch l.Data[0] {
+	case opAppend:
+		count, n := binary.Uvarint(data)
+		if n <= 0 {
+			return applyResult{err: errBadCommand}
+		}
+		data = data[n:]
+		payloads := make([][]byte, 0, count)
+		for i := uint64(0); i < count; i++ {
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return applyResult{err: errBadCommand}
+			}
+			payloads = append(payloads, data[n:n+int(size)])
+			data = data[n+int(size):]
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		first := f.tail + 1
+		for _, p := range payloads {
+			f.tail++
+			f.recs[f.tail] = p
+		}
+		if f.trimTo > f.head {
+			f.trimLocked()
+		}
+		return applyResult{first: first}
+	case opTrim:
+		upTo, n := binary.Uvarint(data)
+		if n <= 0 {
+			return applyResult{err: errBadCommand}
+		}
+		f.trim(upTo)
+		return applyResult{}
+	default:
+		return applyResult{err: fmt.Errorf("%w: op %d", errBadCommand, l.Data[0])}
+	}
+}
+
+func (f *fsm) trim(upTo uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if upTo > f.trimTo {
+		f.trimTo = upTo
+	}
+	f.trimLocked()
+}
+
+// trimLocked discards the records below trimTo that this replica no longer
+// needs. Caller holds mu.
+func (f *fsm) trimLocked() {
+	upTo := f.trimTo
+	if f.retain != nil {
+		if r := f.retain(); r < upTo {
+			upTo = r
+		}
+	}
+	if upTo > f.tail+1 {
+		upTo = f.tail + 1
+	}
+	for gsn := f.head; gsn < upTo; gsn++ {
+		delete(f.recs, gsn)
+	}
+	if upTo > f.head {
+		f.head = upTo
+	}
+}
+
+func (f *fsm) get(gsn uint64) ([]byte, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	data, ok := f.recs[gsn]
+	return data, ok
+}
+
+func (f *fsm) bounds() (head, tail uint64) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.head, f.tail
+}
+
+// Snapshot copies the record map; records are immutable, so the payload
+// slices are shared.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	snap := &fsmSnapshot{head: f.head, tail: f.tail, recs: make(map[uint64][]byte, len(f.recs))}
+	for gsn, data := range f.recs {
+		snap.recs[gsn] = data
+	}
+	return snap, nil
+}
+
+// Restore replaces the state with a snapshot written by fsmSnapshot.Persist:
+// uvarint head, tail, count, then count × (uvarint gsn, uvarint len, payload).
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	r := bufio.NewReader(rc)
+	var hdr [3]uint64
+	for i := range hdr {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("raftlog: restore: %w", err)
+		}
+		hdr[i] = v
+	}
+	recs := make(map[uint64][]byte, hdr[2])
+	for i := uint64(0); i < hdr[2]; i++ {
+		gsn, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("raftlog: restore: %w", err)
+		}
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("raftlog: restore: %w", err)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("raftlog: restore: %w", err)
+		}
+		recs[gsn] = data
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head, f.tail, f.recs = hdr[0], hdr[1], recs
+	// 快照里没有记下没裁完的部分，等 leader 下一次 Trim
+	f.trimTo = f.head
+	return nil
+}
+
+type fsmSnapshot struct {
+	head, tail uint64
+	recs       map[uint64][]byte
+}
+
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	gsns := make([]uint64, 0, len(s.recs))
+	for gsn := range s.recs {
+		gsns = append(gsns, gsn)
+	}
+	sort.Slice(gsns, func(i, j int) bool { return gsns[i] < gsns[j] })
+
+	w := bufio.NewWriter(sink)
+	var buf []byte
+	buf = binary.AppendUvarint(buf, s.head)
+	buf = binary.AppendUvarint(buf, s.tail)
+	buf = binary.AppendUvarint(buf, uint64(len(gsns)))
+	_, err := w.Write(buf)
+	for _, gsn := range gsns {
+		if err != nil {
+			break
+		}
+		data := s.recs[gsn]
+		buf = binary.AppendUvarint(buf[:0], gsn)
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		if _, err = w.Write(buf); err == nil {
+			_, err = w.Write(data)
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) Release() {}
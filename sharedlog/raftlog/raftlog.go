@@ -0,0 +1,367 @@
+// Package raftlog is a SharedLog replicated with Raft among a small set of
+// logstore servers (typically three or five), so that a fault-tolerant
+// deployment needs nothing but the logstore binary.
+//
+// Every replica keeps the whole untrimmed log in memory; durability comes
+// from the Raft log and snapshots on disk. Only the leader accepts appends
+// and trims, other replicas return sharedlog.ErrNotLeader. Reads are served
+// from the local replica and may lag the leader.
+package raftlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// RaftLog does not implement sharedlog.RecordScanner: an entry whose apply
+// timed out may still commit after the local FSM was scanned.
+var _ sharedlog.SharedLog = (*RaftLog)(nil)
+
+// Peer is one member of the initial cluster.
+type Peer struct {
+	ID   string
+	Addr string
+}
+
+type Options struct {
+	// ID identifies this replica; it must appear in Peers.
+	ID string
+	// BindAddr is the Raft transport address. Unused with an in-memory
+	// transport.
+	BindAddr string
+	// Peers is the initial cluster, used to bootstrap a replica that has no
+	// Raft state yet. All replicas must be started with the same Peers.
+	Peers []Peer
+	// OnLeader runs each time this replica becomes leader, after it has
+	// applied every entry committed by earlier leaders and before it accepts
+	// appends. It is meant for bringing the local map service up to date.
+	// ctx is cancelled if leadership is lost meanwhile.
+	OnLeader func(ctx context.Context, l *RaftLog) error
+	// Retain, if set, returns the first GSN this replica still needs, e.g.
+	// the one after its own map checkpoint; 0 keeps everything. Trims are
+	// chosen by the leader, so each replica applies them only up to Retain
+	// and catches up as it moves. Without it a replica whose checkpoint
+	// lags could not recover after a restart. It is called on every apply
+	// and must be cheap.
+	Retain func() uint64
+}
+
+// RaftLog is one replica of the log.
+type RaftLog struct {
+	raft   *raft.Raft
+	fsm    *fsm
+	opts   Options
+	logger hclog.Logger
+
+	// writable is true while this replica is leader and OnLeader has run.
+	writable atomic.Bool
+	gateMu   sync.Mutex
+	gateTerm uint64
+	notifyC  chan bool
+	doneC    chan struct{}
+
+	// closers release the stores and transport after raft shuts down.
+	closers   []func() error
+	closeOnce sync.Once
+}
+
+// NewRaftLog opens a durable replica: Raft log and stable store in
+// dir/raft.db, snapshots in dir, TCP transport on opts.BindAddr.
+func NewRaftLog(dir string, opts Options) (*RaftLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	logger := newLogger(opts.ID)
+	addr, err := net.ResolveTCPAddr("tcp", opts.BindAddr)
+	if err != nil {
+		return nil, err
+	}
+	trans, err := raft.NewTCPTransportWithLogger(opts.BindAddr, addr, 3, 10*time.Second, logger)
+	if err != nil {
+		return nil, err
+	}
+	store, err := raftboltdb.NewBoltStore(filepath.Join(dir, "raft.db"))
+	if err != nil {
+		trans.Close()
+		return nil, err
+	}
+	snaps, err := raft.NewFileSnapshotStoreWithLogger(dir, 2, logger)
+	if err != nil {
+		store.Close()
+		trans.Close()
+		return nil, err
+	}
+	l, err := newRaftLog(opts, store, store, snaps, trans, logger)
+	if err != nil {
+		store.Close()
+		trans.Close()
+		return nil, err
+	}
+	l.closers = append(l.closers, trans.Close, store.Close)
+	return l, nil
+}
+
+// NewInmemRaftLog runs a replica with in-memory stores over trans, e.g. a
+// raft.InmemTransport connected to the other replicas of a test cluster.
+func NewInmemRaftLog(opts Options, trans raft.Transport) (*RaftLog, error) {
+	store := raft.NewInmemStore()
+	return newRaftLog(opts, store, store, raft.NewInmemSnapshotStore(), trans, newLogger(opts.ID))
+}
+
+func newLogger(id string) hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:  "raft-" + id,
+		Level: hclog.Warn,
+	})
+}
+
+func newRaftLog(opts Options, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport, logger hclog.Logger) (*RaftLog, error) {
+	l := &RaftLog{
+		fsm:     newFSM(opts.Retain),
+		opts:    opts,
+		logger:  logger,
+		notifyC: make(chan bool, 1),
+		doneC:   make(chan struct{}),
+	}
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(opts.ID)
+	conf.Logger = logger
+	conf.NotifyCh = l.notifyC
+
+	existing, err := raft.HasExistingState(logs, stable, snaps)
+	if err != nil {
+		return nil, err
+	}
+	if !existing {
+		var cfg raft.Configuration
+		for _, p := range opts.Peers {
+			cfg.Servers = append(cfg.Servers, raft.Server{
+				ID:      raft.ServerID(p.ID),
+				Address: raft.ServerAddress(p.Addr),
+			})
+		}
+		if err := raft.BootstrapCluster(conf, logs, stable, snaps, trans, cfg); err != nil {
+			return nil, fmt.Errorf("raftlog: bootstrap: %w", err)
+		}
+	}
+
+	r, err := raft.NewRaft(conf, l.fsm, logs, stable, snaps, trans)
+	if err != nil {
+		return nil, err
+	}
+	l.raft = r
+	go l.watchLeadership()
+	return l, nil
+}
+
+// watchLeadership gates appends on leadership: after winning an election
+// the replica first applies everything committed so far (Barrier) and runs
+// OnLeader. Each leadership change starts a new term of the gate, so a slow
+// becomeLeader from an earlier term cannot open it.
+func (l *RaftLog) watchLeadership() {
+	defer close(l.doneC)
+	stop := func() {}
+	for leader := range l.notifyC {
+		stop()
+		l.gateMu.Lock()
+		l.gateTerm++
+		term := l.gateTerm
+		l.writable.Store(false)
+		l.gateMu.Unlock()
+		if leader {
+			ctx, cancel := context.WithCancel(context.Background())
+			stop = cancel
+			go l.becomeLeader(ctx, term)
+		}
+	}
+	stop()
+}
+
+func (l *RaftLog) becomeLeader(ctx context.Context, term uint64) {
+	if err := l.raft.Barrier(0).Error(); err != nil {
+		return
+	}
+	if l.opts.OnLeader != nil {
+		if err := l.opts.OnLeader(ctx, l); err != nil {
+			l.logger.Error("OnLeader failed, stepping down", "error", err)
+			l.raft.LeadershipTransfer()
+			return
+		}
+	}
+	l.gateMu.Lock()
+	defer l.gateMu.Unlock()
+	if term == l.gateTerm {
+		l.writable.Store(true)
+	}
+}
+
+// IsLeader reports whether this replica currently accepts appends.
+func (l *RaftLog) IsLeader() bool { return l.writable.Load() }
+
+// Leader returns the Raft address of the current leader, if known.
+func (l *RaftLog) Leader() string {
+	addr, _ := l.raft.LeaderWithID()
+	return string(addr)
+}
+
+func (l *RaftLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	data, err := sharedlog.EncodeData(rec)
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	first, err := l.apply(ctx, encodeAppend([][]byte{data}))
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	return sharedlog.RecordRef{GSN: first}, nil
+}
+
+func (l *RaftLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	data, err := sharedlog.EncodeCommit(rec)
+	if err != nil {
+		return 0, err
+	}
+	return l.apply(ctx, encodeAppend([][]byte{data}))
+}
+
+func (l *RaftLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch replicates the whole batch as one Raft entry, so the records
+// get contiguous GSNs.
+func (l *RaftLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	if len(recs) == 0 {
+		return nil, nil
+	}
+	payloads := make([][]byte, len(recs))
+	for i, rec := range recs {
+		data, err := sharedlog.EncodeRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = data
+	}
+	first, err := l.apply(ctx, encodeAppend(payloads))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]sharedlog.RecordRef, len(recs))
+	for i := range refs {
+		refs[i] = sharedlog.RecordRef{GSN: first + uint64(i)}
+	}
+	return refs, nil
+}
+
+// apply replicates cmd and waits until it is applied locally. If ctx is
+// done first the entry may still commit.
+func (l *RaftLog) apply(ctx context.Context, cmd []byte) (uint64, error) {
+	if !l.writable.Load() {
+		return 0, fmt.Errorf("%w (leader is %q)", sharedlog.ErrNotLeader, l.Leader())
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	future := l.raft.Apply(cmd, 0)
+	errC := make(chan error, 1)
+	go func() { errC <- future.Error() }()
+	select {
+	case err := <-errC:
+		if errors.Is(err, raft.ErrNotLeader) {
+			return 0, fmt.Errorf("%w (leader is %q)", sharedlog.ErrNotLeader, l.Leader())
+		}
+		if err != nil {
+			return 0, err
+		}
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	res := future.Response().(applyResult)
+	return res.first, res.err
+}
+
+func (l *RaftLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return sharedlog.DataRecord{}, err
+	}
+	data, ok := l.fsm.get(ref.GSN)
+	if !ok {
+		return sharedlog.DataRecord{}, fmt.Errorf("data record not found: gsn=%d", ref.GSN)
+	}
+	return sharedlog.DecodeData(data)
+}
+
+func (l *RaftLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	for gsn := from; gsn <= to; gsn++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, ok := l.fsm.get(gsn)
+		if !ok {
+			continue
+		}
+		rec, err := sharedlog.DecodeCommit(data)
+		if errors.Is(err, sharedlog.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := handler(gsn, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe polls the local FSM, so it also works on followers.
+func (l *RaftLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	return sharedlog.PollCommits(ctx, l, from, sharedlog.DefaultPollInterval, handler)
+}
+
+func (l *RaftLog) Head(ctx context.Context) uint64 {
+	head, _ := l.fsm.bounds()
+	return head
+}
+
+// Tail returns the largest GSN applied on this replica.
+func (l *RaftLog) Tail(ctx context.Context) uint64 {
+	_, tail := l.fsm.bounds()
+	return tail
+}
+
+// Trim replicates a trim to every replica. Each one discards records only
+// up to its Options.Retain, so Head can differ between replicas.
+func (l *RaftLog) Trim(ctx context.Context, upTo uint64) error {
+	_, err := l.apply(ctx, encodeTrim(upTo))
+	return err
+}
+
+// Close shuts the replica down.
+func (l *RaftLog) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		err = l.raft.Shutdown().Error()
+		close(l.notifyC)
+		<-l.doneC
+		for _, c := range l.closers {
+			if e := c(); err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
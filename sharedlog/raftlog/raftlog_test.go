@@ -0,0 +1,176 @@
+package raftlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// cluster starts n in-memory replicas; opts[i] customises replica i.
+func cluster(t *testing.T, n int, opts func(i int, o *Options)) []*RaftLog {
+	t.Helper()
+	var peers []Peer
+	trans := make([]*raft.InmemTransport, n)
+	for i := range trans {
+		addr, tr := raft.NewInmemTransport("")
+		trans[i] = tr
+		peers = append(peers, Peer{ID: fmt.Sprintf("r%d", i), Addr: string(addr)})
+	}
+	for _, a := range trans {
+		for _, b := range trans {
+			if a != b {
+				a.Connect(b.LocalAddr(), b)
+			}
+		}
+	}
+	logs := make([]*RaftLog, n)
+	for i := range logs {
+		o := Options{ID: peers[i].ID, Peers: peers}
+		if opts != nil {
+			opts(i, &o)
+		}
+		l, err := NewInmemRaftLog(o, trans[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		logs[i] = l
+		t.Cleanup(func() { l.Close() })
+	}
+	return logs
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func leader(t *testing.T, logs []*RaftLog, except *RaftLog) *RaftLog {
+	t.Helper()
+	var found *RaftLog
+	waitFor(t, "a leader", func() bool {
+		for _, l := range logs {
+			if l != except && l.IsLeader() {
+				found = l
+				return true
+			}
+		}
+		return false
+	})
+	return found
+}
+
+func commit(t *testing.T, l *RaftLog, key, value string) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	ref, err := l.AppendData(ctx, sharedlog.DataRecord{Key: key, Value: []byte(value)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gsn, err := l.AppendCommit(ctx, sharedlog.CommitRecord{Entries: []sharedlog.CommitEntry{{Key: key, Ref: ref}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gsn
+}
+
+func TestLeaderChange(t *testing.T) {
+	ctx := context.Background()
+	var caughtUp atomic.Int32
+	logs := cluster(t, 3, func(i int, o *Options) {
+		o.OnLeader = func(ctx context.Context, l *RaftLog) error {
+			caughtUp.Add(1)
+			return nil
+		}
+	})
+	first := leader(t, logs, nil)
+	for _, l := range logs {
+		if l == first {
+			continue
+		}
+		if _, err := l.AppendData(ctx, sharedlog.DataRecord{Key: "k"}); !errors.Is(err, sharedlog.ErrNotLeader) {
+			t.Fatalf("append on a follower: err = %v, want ErrNotLeader", err)
+		}
+	}
+	gsn := commit(t, first, "a", "1")
+
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	second := leader(t, logs, first)
+	if caughtUp.Load() < 2 {
+		t.Fatalf("OnLeader ran %d times, want it for each leader", caughtUp.Load())
+	}
+	if tail := second.Tail(ctx); tail < gsn {
+		t.Fatalf("new leader's tail %d is below the committed GSN %d", tail, gsn)
+	}
+	next := commit(t, second, "b", "2")
+	if next <= gsn {
+		t.Fatalf("new leader committed at %d, not after %d", next, gsn)
+	}
+	var keys []string
+	err := second.ReplayCommits(ctx, second.Head(ctx), second.Tail(ctx), func(_ uint64, rec sharedlog.CommitRecord) error {
+		keys = append(keys, rec.Entries[0].Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keys) != "[a b]" {
+		t.Fatalf("commits after leader change = %v, want [a b]", keys)
+	}
+}
+
+func TestTrimKeepsRetained(t *testing.T) {
+	ctx := context.Background()
+	// replica 1 still needs everything from GSN 2 on
+	var retain atomic.Uint64
+	retain.Store(2)
+	logs := cluster(t, 3, func(i int, o *Options) {
+		if i == 1 {
+			o.Retain = retain.Load
+		}
+	})
+	l := leader(t, logs, nil)
+	if l == logs[1] {
+		l.raft.LeadershipTransfer()
+		l = leader(t, logs, logs[1])
+	}
+	for i := 0; i < 3; i++ {
+		commit(t, l, fmt.Sprint(i), "v")
+	}
+	if err := l.Trim(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	// the trim does not move Tail; an entry after it shows it was applied
+	commit(t, l, "y", "v")
+	waitFor(t, "the trim to apply", func() bool {
+		for _, r := range logs {
+			if r != logs[1] && r.Head(ctx) != 5 {
+				return false
+			}
+		}
+		return logs[1].Tail(ctx) == l.Tail(ctx)
+	})
+	if h := logs[1].Head(ctx); h != 2 {
+		t.Fatalf("head of the lagging replica = %d, want 2", h)
+	}
+
+	// once the replica moves on it trims the rest with the next entry
+	retain.Store(10)
+	commit(t, l, "x", "v")
+	waitFor(t, "the lagging replica to trim", func() bool { return logs[1].Head(ctx) == 5 })
+}
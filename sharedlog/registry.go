@@ -0,0 +1,74 @@
+package sharedlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is what a backend reads its settings from. *viper.Viper satisfies
+// it; keys are prefixed with the backend name, e.g. "filelog-dir".
+type Config interface {
+	IsSet(key string) bool
+	GetString(key string) string
+	GetInt(key string) int
+	GetInt64(key string) int64
+	GetDuration(key string) time.Duration
+	GetStringSlice(key string) []string
+}
+
+// Hooks are the callbacks a server hands to the backend it opens. Backends
+// that have no use for a hook ignore it; any hook may be nil.
+type Hooks struct {
+	// OnLeader runs when this replica of a replicated log becomes the
+	// leader, before it accepts appends.
+	OnLeader func(ctx context.Context, l SharedLog) error
+	// Retain returns the first GSN the server still needs. A backend that
+	// keeps its own copy of the log must not discard records from there on.
+	Retain func() uint64
+}
+
+// Opener creates a backend from its configuration.
+type Opener func(cfg Config, hooks Hooks) (SharedLog, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Opener)
+)
+
+// RegisterBackend makes a backend available to OpenBackend under name.
+// Backend packages call it from init. It panics if name is taken.
+func RegisterBackend(name string, open Opener) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, dup := backends[name]; dup {
+		panic("sharedlog: backend " + name + " registered twice")
+	}
+	backends[name] = open
+}
+
+// OpenBackend opens the backend registered under name.
+func OpenBackend(name string, cfg Config, hooks Hooks) (SharedLog, error) {
+	backendsMu.RLock()
+	open, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("sharedlog: unknown backend %q (have %s)", name, strings.Join(Backends(), ", "))
+	}
+	return open(cfg, hooks)
+}
+
+// Backends returns the registered backend names in sorted order.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
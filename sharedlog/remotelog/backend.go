@@ -0,0 +1,34 @@
+package remotelog
+
+import (
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// The "remote" backend uses the Log service of another server at
+// remotelog-addr.
+func init() {
+	sharedlog.RegisterBackend("remote", func(cfg sharedlog.Config, _ sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		addr := cfg.GetString("remotelog-addr")
+		if addr == "" {
+			return nil, errors.New("remotelog: remotelog-addr not set")
+		}
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		return &ownedRemoteLog{RemoteLog: NewRemoteLog(conn), conn: conn}, nil
+	})
+}
+
+// ownedRemoteLog is a RemoteLog that owns its connection.
+type ownedRemoteLog struct {
+	*RemoteLog
+	conn *grpc.ClientConn
+}
+
+func (l *ownedRemoteLog) Close() error { return l.conn.Close() }
@@ -0,0 +1,132 @@
+package remotelog
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/logpb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// SharedLog errors that travel as gRPC status codes. NotLeader is not
+// Unavailable, which gRPC itself uses for connection failures.
+var logErrCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{sharedlog.ErrTrimNotSupported, codes.Unimplemented},
+	{sharedlog.ErrNotLeader, codes.FailedPrecondition},
+	{sharedlog.ErrTrimmed, codes.OutOfRange},
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, e := range logErrCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, err.Error())
+		}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+// fromStatus turns a status back into an error that errors.Is matches
+// against the sharedlog and context errors.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, e := range logErrCodes {
+		if st.Code() == e.code {
+			return &remoteError{msg: st.Message(), kind: e.err}
+		}
+	}
+	switch st.Code() {
+	case codes.Canceled:
+		return &remoteError{msg: st.Message(), kind: context.Canceled}
+	case codes.DeadlineExceeded:
+		return &remoteError{msg: st.Message(), kind: context.DeadlineExceeded}
+	}
+	return err
+}
+
+type remoteError struct {
+	msg  string
+	kind error
+}
+
+func (e *remoteError) Error() string { return "remotelog: " + e.msg }
+func (e *remoteError) Unwrap() error { return e.kind }
+
+func refToPB(ref sharedlog.RecordRef) *logpb.RecordRef {
+	return &logpb.RecordRef{Gsn: ref.GSN, ShardId: ref.ShardID}
+}
+
+func refFromPB(ref *logpb.RecordRef) sharedlog.RecordRef {
+	return sharedlog.RecordRef{GSN: ref.GetGsn(), ShardID: ref.GetShardId()}
+}
+
+func dataToPB(rec sharedlog.DataRecord) *logpb.DataRecord {
+	return &logpb.DataRecord{Key: rec.Key, Value: rec.Value, TxnId: rec.TxnID, Token: rec.Token}
+}
+
+func dataFromPB(rec *logpb.DataRecord) sharedlog.DataRecord {
+	return sharedlog.DataRecord{Key: rec.GetKey(), Value: rec.GetValue(), TxnID: rec.GetTxnId(), Token: rec.GetToken()}
+}
+
+func commitToPB(rec sharedlog.CommitRecord) *logpb.CommitRecord {
+	entries := make([]*logpb.CommitEntry, len(rec.Entries))
+	for i, e := range rec.Entries {
+		entries[i] = &logpb.CommitEntry{Key: e.Key, Ref: refToPB(e.Ref), Tombstone: e.Tombstone}
+	}
+	return &logpb.CommitRecord{Entries: entries, TxnId: rec.TxnID, Token: rec.Token, ClientId: rec.ClientID, Seq: rec.Seq}
+}
+
+func commitFromPB(rec *logpb.CommitRecord) sharedlog.CommitRecord {
+	entries := make([]sharedlog.CommitEntry, len(rec.GetEntries()))
+	for i, e := range rec.GetEntries() {
+		entries[i] = sharedlog.CommitEntry{Key: e.Key, Ref: refFromPB(e.Ref), Tombstone: e.Tombstone}
+	}
+	return sharedlog.CommitRecord{
+		Entries:  entries,
+		TxnID:    rec.GetTxnId(),
+		Token:    rec.GetToken(),
+		ClientID: rec.GetClientId(),
+		Seq:      rec.GetSeq(),
+	}
+}
+
+func recordToPB(rec sharedlog.Record) (*logpb.Record, error) {
+	switch {
+	case rec.Type == sharedlog.RecordTypeData && rec.Data != nil:
+		return &logpb.Record{Body: &logpb.Record_Data{Data: dataToPB(*rec.Data)}}, nil
+	case rec.Type == sharedlog.RecordTypeCommit && rec.Commit != nil:
+		return &logpb.Record{Body: &logpb.Record_Commit{Commit: commitToPB(*rec.Commit)}}, nil
+	default:
+		return nil, errors.New("remotelog: record without payload")
+	}
+}
+
+func recordFromPB(rec *logpb.Record) (sharedlog.Record, error) {
+	switch body := rec.GetBody().(type) {
+	case *logpb.Record_Data:
+		d := dataFromPB(body.Data)
+		return sharedlog.Record{Type: sharedlog.RecordTypeData, Data: &d}, nil
+	case *logpb.Record_Commit:
+		c := commitFromPB(body.Commit)
+		return sharedlog.Record{Type: sharedlog.RecordTypeCommit, Commit: &c}, nil
+	default:
+		return sharedlog.Record{}, errors.New("remotelog: record without payload")
+	}
+}
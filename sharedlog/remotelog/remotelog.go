@@ -0,0 +1,171 @@
+// Package remotelog serves a SharedLog over gRPC (Server) and implements
+// SharedLog on top of that service (RemoteLog), so that a StorageServer can
+// run in a different process from the log backend.
+package remotelog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/logpb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+var (
+	_ sharedlog.SharedLog    = (*RemoteLog)(nil)
+	_ sharedlog.BoundsReader = (*RemoteLog)(nil)
+)
+
+// RemoteLog is a SharedLog client for the Log service. The caller owns the
+// connection.
+type RemoteLog struct {
+	c logpb.LogClient
+
+	// last bounds seen, returned by Head/Tail when the server is unreachable
+	mu       sync.Mutex
+	lastHead uint64
+	lastTail uint64
+}
+
+func NewRemoteLog(conn grpc.ClientConnInterface) *RemoteLog {
+	return &RemoteLog{c: logpb.NewLogClient(conn)}
+}
+
+func (l *RemoteLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	refs, err := l.AppendDataBatch(ctx, []sharedlog.DataRecord{rec})
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	return refs[0], nil
+}
+
+func (l *RemoteLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	refs, err := l.AppendBatch(ctx, sharedlog.CommitRecords([]sharedlog.CommitRecord{rec}))
+	if err != nil {
+		return 0, err
+	}
+	return refs[0].GSN, nil
+}
+
+func (l *RemoteLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+func (l *RemoteLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	req := &logpb.AppendRequest{Records: make([]*logpb.Record, len(recs))}
+	for i, rec := range recs {
+		r, err := recordToPB(rec)
+		if err != nil {
+			return nil, err
+		}
+		req.Records[i] = r
+	}
+	resp, err := l.c.Append(ctx, req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	if len(resp.Refs) != len(recs) {
+		return nil, errors.New("remotelog: server returned a wrong number of refs")
+	}
+	refs := make([]sharedlog.RecordRef, len(resp.Refs))
+	for i, ref := range resp.Refs {
+		refs[i] = refFromPB(ref)
+	}
+	if len(resp.Errors) == 0 {
+		return refs, nil
+	}
+	errs := make([]error, len(recs))
+	for _, e := range resp.Errors {
+		if int(e.Index) >= len(errs) {
+			return nil, errors.New("remotelog: server returned an error for a record out of range")
+		}
+		errs[e.Index] = fromStatus(status.Error(codes.Code(e.Code), e.Message))
+	}
+	return sharedlog.BatchResult(refs, errs)
+}
+
+func (l *RemoteLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	resp, err := l.c.Read(ctx, &logpb.ReadRequest{Ref: refToPB(ref)})
+	if err != nil {
+		return sharedlog.DataRecord{}, fromStatus(err)
+	}
+	return dataFromPB(resp.Record), nil
+}
+
+func (l *RemoteLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	// cancel the stream if handler fails midway
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := l.c.ReadRange(ctx, &logpb.ReadRangeRequest{FromGsn: from, ToGsn: to})
+	if err != nil {
+		return fromStatus(err)
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fromStatus(err)
+		}
+		if err := handler(msg.Gsn, commitFromPB(msg.Record)); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *RemoteLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := l.c.Subscribe(ctx, &logpb.SubscribeRequest{FromGsn: from})
+	if err != nil {
+		return fromStatus(err)
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			// the server only ends the stream with an error
+			return fromStatus(err)
+		}
+		if err := handler(msg.Gsn, commitFromPB(msg.Record)); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *RemoteLog) Head(ctx context.Context) uint64 {
+	head, _, _ := l.Bounds(ctx)
+	return head
+}
+
+func (l *RemoteLog) Tail(ctx context.Context) uint64 {
+	_, tail, _ := l.Bounds(ctx)
+	return tail
+}
+
+// Bounds asks the server for its head and tail. If the server cannot be
+// reached it returns the last bounds seen together with the error.
+func (l *RemoteLog) Bounds(ctx context.Context) (uint64, uint64, error) {
+	resp, err := l.c.Tail(ctx, &logpb.TailRequest{})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		return l.lastHead, l.lastTail, fromStatus(err)
+	}
+	l.lastHead, l.lastTail = resp.Head, resp.Tail
+	return resp.Head, resp.Tail, nil
+}
+
+func (l *RemoteLog) Trim(ctx context.Context, upTo uint64) error {
+	_, err := l.c.Trim(ctx, &logpb.TrimRequest{UpTo: upTo})
+	return fromStatus(err)
+}
+
+// Close is a no-op: the caller owns the connection.
+func (l *RemoteLog) Close() error { return nil }
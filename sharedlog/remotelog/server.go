@@ -0,0 +1,111 @@
+package remotelog
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/logpb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// Server serves a SharedLog over the Log gRPC service.
+type Server struct {
+	logpb.UnimplementedLogServer
+	log sharedlog.SharedLog
+	// trimLimit returns the highest GSN a client may trim up to; nil allows
+	// any trim.
+	trimLimit func() uint64
+}
+
+// NewServer serves l. Trims above trimLimit() are refused, so that a
+// client cannot discard records the local map still needs.
+func NewServer(l sharedlog.SharedLog, trimLimit func() uint64) *Server {
+	return &Server{log: l, trimLimit: trimLimit}
+}
+
+// Register serves l on s.
+func Register(s *grpc.Server, l sharedlog.SharedLog, trimLimit func() uint64) {
+	logpb.RegisterLogServer(s, NewServer(l, trimLimit))
+}
+
+func (s *Server) Append(ctx context.Context, req *logpb.AppendRequest) (*logpb.AppendResponse, error) {
+	recs := make([]sharedlog.Record, len(req.Records))
+	for i, r := range req.Records {
+		rec, err := recordFromPB(r)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "record %d: %v", i, err)
+		}
+		recs[i] = rec
+	}
+	refs, err := s.log.AppendBatch(ctx, recs)
+	var be *sharedlog.BatchError
+	if err != nil && !errors.As(err, &be) {
+		return nil, toStatus(err)
+	}
+	resp := &logpb.AppendResponse{Refs: make([]*logpb.RecordRef, len(refs))}
+	for i, ref := range refs {
+		resp.Refs[i] = refToPB(ref)
+	}
+	// 部分记录已经写入：把每条失败记录的错误带回去，而不是整体报错
+	if be != nil {
+		for i, err := range be.Errs {
+			if err == nil {
+				continue
+			}
+			st := status.Convert(toStatus(err))
+			resp.Errors = append(resp.Errors, &logpb.AppendError{
+				Index:   uint32(i),
+				Code:    uint32(st.Code()),
+				Message: st.Message(),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) Read(ctx context.Context, req *logpb.ReadRequest) (*logpb.ReadResponse, error) {
+	rec, err := s.log.ReadData(ctx, refFromPB(req.Ref))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &logpb.ReadResponse{Record: dataToPB(rec)}, nil
+}
+
+func (s *Server) ReadRange(req *logpb.ReadRangeRequest, stream grpc.ServerStreamingServer[logpb.CommitAt]) error {
+	err := s.log.ReplayCommits(stream.Context(), req.FromGsn, req.ToGsn, func(gsn uint64, rec sharedlog.CommitRecord) error {
+		return stream.Send(&logpb.CommitAt{Gsn: gsn, Record: commitToPB(rec)})
+	})
+	return toStatus(err)
+}
+
+func (s *Server) Subscribe(req *logpb.SubscribeRequest, stream grpc.ServerStreamingServer[logpb.CommitAt]) error {
+	err := s.log.Subscribe(stream.Context(), req.FromGsn, func(gsn uint64, rec sharedlog.CommitRecord) error {
+		return stream.Send(&logpb.CommitAt{Gsn: gsn, Record: commitToPB(rec)})
+	})
+	return toStatus(err)
+}
+
+func (s *Server) Tail(ctx context.Context, req *logpb.TailRequest) (*logpb.TailResponse, error) {
+	head, tail, err := sharedlog.Bounds(ctx, s.log)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &logpb.TailResponse{Head: head, Tail: tail}, nil
+}
+
+func (s *Server) Trim(ctx context.Context, req *logpb.TrimRequest) (*logpb.TrimResponse, error) {
+	// not FailedPrecondition, which clients read as ErrNotLeader
+	if s.trimLimit != nil {
+		if limit := s.trimLimit(); req.UpTo > limit {
+			return nil, status.Errorf(codes.PermissionDenied, "trim up to %d: records from %d on are still needed", req.UpTo, limit)
+		}
+	}
+	if err := s.log.Trim(ctx, req.UpTo); err != nil {
+		return nil, toStatus(err)
+	}
+	return &logpb.TrimResponse{}, nil
+}
@@ -0,0 +1,83 @@
+package remotelog
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// serve serves l with trimLimit and returns a client for it and a func that
+// stops the server.
+func serve(t *testing.T, l sharedlog.SharedLog, trimLimit func() uint64) (*RemoteLog, func()) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, l, trimLimit)
+	go s.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return NewRemoteLog(conn), s.Stop
+}
+
+func TestTrimLimit(t *testing.T) {
+	ctx := context.Background()
+	inner := memorylog.NewMemoryLog()
+	for i := 0; i < 5; i++ {
+		if _, err := inner.AppendData(ctx, sharedlog.DataRecord{Key: "k"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l, _ := serve(t, inner, func() uint64 { return 3 })
+
+	if err := l.Trim(ctx, 4); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("trim above the limit: err = %v, want PermissionDenied", err)
+	}
+	if head := inner.Head(ctx); head != 1 {
+		t.Fatalf("head = %d after a refused trim, want 1", head)
+	}
+	if err := l.Trim(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if head := inner.Head(ctx); head != 3 {
+		t.Fatalf("head = %d, want 3", head)
+	}
+}
+
+func TestBoundsUnreachable(t *testing.T) {
+	ctx := context.Background()
+	inner := memorylog.NewMemoryLog()
+	if _, err := inner.AppendData(ctx, sharedlog.DataRecord{Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	l, stop := serve(t, inner, nil)
+	head, tail, err := l.Bounds(ctx)
+	if err != nil || head != 1 || tail != 1 {
+		t.Fatalf("Bounds = %d, %d, %v, want 1, 1", head, tail, err)
+	}
+
+	stop()
+	if _, _, err := l.Bounds(ctx); err == nil {
+		t.Fatal("Bounds of a stopped server succeeded")
+	}
+	// Head and Tail keep answering with the last bounds seen
+	if head, tail := l.Head(ctx), l.Tail(ctx); head != 1 || tail != 1 {
+		t.Fatalf("Head, Tail = %d, %d after the server stopped, want the cached 1, 1", head, tail)
+	}
+}
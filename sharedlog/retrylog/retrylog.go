@@ -0,0 +1,388 @@
+// Package retrylog retries SharedLog calls that fail with transient errors.
+//
+// Retrying an append is only safe if the failed attempt did not reach the
+// log. RetryLog tags every append with an idempotency token and, before
+// trying again, scans the log from a tail known to precede the first attempt
+// for records carrying those tokens; records that did land are returned with
+// their original GSN instead of being written twice. This needs a backend
+// that implements sharedlog.RecordScanner, whose scans settle failed
+// appends; on other backends (Scalog, Raft) appends are not retried.
+package retrylog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+var (
+	_ sharedlog.SharedLog    = (*RetryLog)(nil)
+	_ sharedlog.BoundsReader = (*RetryLog)(nil)
+)
+
+type Options struct {
+	// MaxAttempts bounds the attempts per call, the first one included.
+	MaxAttempts int
+	// The wait before the n-th retry is InitialBackoff * 2^(n-1), capped at
+	// MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retryable classifies errors; nil means IsTransient.
+	Retryable func(error) bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+}
+
+// IsTransient reports whether err may go away on retry: gRPC Unavailable,
+// ResourceExhausted and Aborted, and errors with a Temporary() method that
+// returns true. Context errors are never transient, the caller has given up,
+// and neither is ErrNotLeader: this replica will not become the leader
+// within a few backoffs, the client should go to the one that is.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, sharedlog.ErrNotLeader) {
+		return false
+	}
+	var temp interface{ Temporary() bool }
+	if errors.As(err, &temp) {
+		return temp.Temporary()
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+	return false
+}
+
+// RetryLog wraps a SharedLog and retries its failing calls. Wrap the
+// backend directly, below decorators such as cachelog, so that the scan for
+// tokens sees the backend's RecordScanner.
+type RetryLog struct {
+	sharedlog.SharedLog
+	scanner sharedlog.RecordScanner // nil if appends cannot be retried
+	opts    Options
+
+	// observed 是已经见过的最大 GSN（NewRetryLog 时的 Tail，以及成功 append 的
+	// ref），不超过当前 tail；append 失败后从这里开始找 token，正常路径不用探 Tail
+	observed atomic.Uint64
+}
+
+func NewRetryLog(inner sharedlog.SharedLog, opts Options) *RetryLog {
+	if opts.Retryable == nil {
+		opts.Retryable = IsTransient
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	l := &RetryLog{SharedLog: inner, opts: opts}
+	l.scanner, _ = inner.(sharedlog.RecordScanner)
+	if l.scanner != nil {
+		l.observed.Store(inner.Tail(context.Background()))
+	}
+	return l
+}
+
+func (l *RetryLog) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	refs, err := l.AppendBatch(ctx, sharedlog.DataRecords([]sharedlog.DataRecord{rec}))
+	if err != nil {
+		return sharedlog.RecordRef{}, err
+	}
+	return refs[0], nil
+}
+
+func (l *RetryLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	refs, err := l.AppendBatch(ctx, sharedlog.CommitRecords([]sharedlog.CommitRecord{rec}))
+	if err != nil {
+		return 0, err
+	}
+	return refs[0].GSN, nil
+}
+
+func (l *RetryLog) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return l.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch appends recs, giving each record without a token a fresh one.
+// After a transient failure the records already in the log are looked up by
+// token and only the rest are appended again. If it gives up after some
+// records landed, it returns their refs with a *sharedlog.BatchError.
+func (l *RetryLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	if l.scanner == nil {
+		return l.SharedLog.AppendBatch(ctx, recs)
+	}
+	recs, tokens, err := withTokens(recs)
+	if err != nil {
+		return nil, err
+	}
+	// 第一次尝试之前已经见过的 GSN：失败的 append 如果落地了，一定在它之后
+	from := l.observed.Load()
+
+	refs := make([]sharedlog.RecordRef, len(recs))
+	landed := make([]bool, len(recs))
+	pending := make([]int, len(recs)) // indexes of recs not known to be in the log
+	for i := range pending {
+		pending[i] = i
+	}
+	for attempt := 1; ; attempt++ {
+		batch := make([]sharedlog.Record, len(pending))
+		for j, i := range pending {
+			batch[j] = recs[i]
+		}
+		got, err := l.SharedLog.AppendBatch(ctx, batch)
+		if err == nil {
+			for j, i := range pending {
+				refs[i], landed[i] = got[j], true
+			}
+			l.observe(refs)
+			return refs, nil
+		}
+		// 部分成功：已经落地的记录不用再找
+		if got != nil {
+			errs := sharedlog.RecordErrors(len(batch), err)
+			rest := pending[:0]
+			for j, i := range pending {
+				if errs[j] == nil {
+					refs[i], landed[i] = got[j], true
+				} else {
+					rest = append(rest, i)
+					err = errs[j]
+				}
+			}
+			pending = rest
+		}
+		if !l.opts.Retryable(err) || attempt == l.opts.MaxAttempts {
+			return l.giveUp(refs, landed, err)
+		}
+		if werr := l.wait(ctx, attempt); werr != nil {
+			return l.giveUp(refs, landed, err)
+		}
+
+		want := make(map[string]int, len(pending))
+		for _, i := range pending {
+			want[tokens[i]] = i
+		}
+		if serr := l.find(ctx, from, want, refs, landed); serr != nil {
+			// 不确定哪些已经写进去了，不能重试
+			return l.giveUp(refs, landed, err)
+		}
+		pending = pending[:0]
+		for _, i := range want {
+			pending = append(pending, i)
+		}
+		if len(pending) == 0 {
+			l.observe(refs)
+			return refs, nil
+		}
+		sort.Ints(pending)
+	}
+}
+
+// giveUp returns err for an append that failed, as a *sharedlog.BatchError
+// if some of its records are known to be in the log.
+func (l *RetryLog) giveUp(refs []sharedlog.RecordRef, landed []bool, err error) ([]sharedlog.RecordRef, error) {
+	errs := make([]error, len(refs))
+	some := false
+	for i := range refs {
+		if landed[i] {
+			some = true
+		} else {
+			errs[i] = err
+		}
+	}
+	if !some {
+		return nil, err
+	}
+	l.observe(refs)
+	return sharedlog.BatchResult(refs, errs)
+}
+
+func (l *RetryLog) observe(refs []sharedlog.RecordRef) {
+	for _, ref := range refs {
+		for {
+			cur := l.observed.Load()
+			if ref.GSN <= cur || l.observed.CompareAndSwap(cur, ref.GSN) {
+				break
+			}
+		}
+	}
+}
+
+// find scans [from, Tail()] for the tokens in want, stores the refs of the
+// records found and removes their tokens from want.
+func (l *RetryLog) find(ctx context.Context, from uint64, want map[string]int, refs []sharedlog.RecordRef, landed []bool) error {
+	// a stale tail could end the scan before a record that landed
+	_, tail, err := sharedlog.Bounds(ctx, l.SharedLog)
+	if err != nil {
+		return err
+	}
+	errDone := errors.New("done")
+	err = l.scanner.ScanRecords(ctx, from, tail, func(ref sharedlog.RecordRef, rec sharedlog.Record) error {
+		if i, ok := want[rec.Token]; ok && rec.Token != "" {
+			refs[i], landed[i] = ref, true
+			delete(want, rec.Token)
+			if len(want) == 0 {
+				return errDone
+			}
+		}
+		return nil
+	})
+	if err == errDone {
+		return nil
+	}
+	return err
+}
+
+func (l *RetryLog) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	var rec sharedlog.DataRecord
+	err := l.retry(ctx, func() error {
+		var err error
+		rec, err = l.SharedLog.ReadData(ctx, ref)
+		return err
+	})
+	return rec, err
+}
+
+// ReplayCommits resumes after the last delivered commit when the backend
+// fails; errors from handler are returned as is.
+func (l *RetryLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	return l.resume(ctx, from, handler, func(from uint64, h func(uint64, sharedlog.CommitRecord) error) error {
+		return l.SharedLog.ReplayCommits(ctx, from, to, h)
+	})
+}
+
+func (l *RetryLog) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	if from == 0 {
+		from = l.SharedLog.Head(ctx)
+	}
+	return l.resume(ctx, from, handler, func(from uint64, h func(uint64, sharedlog.CommitRecord) error) error {
+		return l.SharedLog.Subscribe(ctx, from, h)
+	})
+}
+
+// Bounds retries sharedlog.Bounds on the underlying log.
+func (l *RetryLog) Bounds(ctx context.Context) (head, tail uint64, err error) {
+	err = l.retry(ctx, func() error {
+		var err error
+		head, tail, err = sharedlog.Bounds(ctx, l.SharedLog)
+		return err
+	})
+	return head, tail, err
+}
+
+func (l *RetryLog) Trim(ctx context.Context, upTo uint64) error {
+	return l.retry(ctx, func() error { return l.SharedLog.Trim(ctx, upTo) })
+}
+
+// handlerError marks errors returned by the caller's handler.
+type handlerError struct{ err error }
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+func (l *RetryLog) resume(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error,
+	run func(from uint64, h func(uint64, sharedlog.CommitRecord) error) error) error {
+	next := from
+	h := func(gsn uint64, rec sharedlog.CommitRecord) error {
+		if err := handler(gsn, rec); err != nil {
+			return &handlerError{err}
+		}
+		next = gsn + 1
+		return nil
+	}
+	err := l.retry(ctx, func() error { return run(next, h) })
+	var herr *handlerError
+	if errors.As(err, &herr) {
+		return herr.err
+	}
+	return err
+}
+
+// retry runs an idempotent call until it succeeds, fails permanently or
+// runs out of attempts.
+func (l *RetryLog) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		var herr *handlerError
+		if err == nil || errors.As(err, &herr) || !l.opts.Retryable(err) || attempt == l.opts.MaxAttempts {
+			return err
+		}
+		if werr := l.wait(ctx, attempt); werr != nil {
+			return err
+		}
+	}
+}
+
+func (l *RetryLog) wait(ctx context.Context, attempt int) error {
+	d := l.opts.InitialBackoff
+	for i := 1; i < attempt && d < l.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > l.opts.MaxBackoff {
+		d = l.opts.MaxBackoff
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withTokens returns a copy of recs in which every record has a token, and
+// the tokens by index.
+func withTokens(recs []sharedlog.Record) ([]sharedlog.Record, []string, error) {
+	out := make([]sharedlog.Record, len(recs))
+	tokens := make([]string, len(recs))
+	for i, rec := range recs {
+		switch {
+		case rec.Type == sharedlog.RecordTypeData && rec.Data != nil:
+			d := *rec.Data
+			if d.Token == "" {
+				tok, err := newToken()
+				if err != nil {
+					return nil, nil, err
+				}
+				d.Token = tok
+			}
+			rec.Data, tokens[i] = &d, d.Token
+		case rec.Type == sharedlog.RecordTypeCommit && rec.Commit != nil:
+			c := *rec.Commit
+			if c.Token == "" {
+				tok, err := newToken()
+				if err != nil {
+					return nil, nil, err
+				}
+				c.Token = tok
+			}
+			rec.Commit, tokens[i] = &c, c.Token
+		}
+		out[i] = rec
+	}
+	return out, tokens, nil
+}
+
+func newToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
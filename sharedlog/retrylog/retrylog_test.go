@@ -0,0 +1,127 @@
+package retrylog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// flakyLog fails the first failures appends with err. If land is set the
+// failed appends still reach the log, as when the reply is lost.
+type flakyLog struct {
+	*memorylog.MemoryLog
+	failures int
+	land     bool
+	err      error
+	attempts int
+}
+
+func (l *flakyLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	l.attempts++
+	if l.attempts > l.failures {
+		return l.MemoryLog.AppendBatch(ctx, recs)
+	}
+	if l.land {
+		if _, err := l.MemoryLog.AppendBatch(ctx, recs); err != nil {
+			return nil, err
+		}
+	}
+	return nil, l.err
+}
+
+var errUnavailable = status.Error(codes.Unavailable, "connection reset")
+
+func testOptions() Options {
+	return Options{MaxAttempts: 4, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+}
+
+func TestRetryFindsLandedAppend(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyLog{MemoryLog: memorylog.NewMemoryLog(), failures: 1, land: true, err: errUnavailable}
+	l := NewRetryLog(inner, testOptions())
+	if _, err := inner.MemoryLog.AppendData(ctx, sharedlog.DataRecord{Key: "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := l.AppendData(ctx, sharedlog.DataRecord{Key: "k", Value: []byte("v")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.attempts != 1 {
+		t.Fatalf("%d appends reached the log, want only the first", inner.attempts)
+	}
+	if tail := inner.Tail(ctx); tail != ref.GSN {
+		t.Fatalf("tail = %d, want the landed record at %d and nothing after it", tail, ref.GSN)
+	}
+	rec, err := l.ReadData(ctx, ref)
+	if err != nil || rec.Key != "k" {
+		t.Fatalf("ReadData(%d) = %v, %v", ref.GSN, rec, err)
+	}
+}
+
+func TestRetryAppendsLostAttempt(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyLog{MemoryLog: memorylog.NewMemoryLog(), failures: 2, err: errUnavailable}
+	l := NewRetryLog(inner, testOptions())
+	gsn, err := l.AppendCommit(ctx, sharedlog.CommitRecord{Entries: []sharedlog.CommitEntry{{Key: "k"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.attempts != 3 {
+		t.Fatalf("%d attempts, want 3", inner.attempts)
+	}
+	if tail := inner.Tail(ctx); tail != gsn {
+		t.Fatalf("tail = %d, want the one commit at %d", tail, gsn)
+	}
+}
+
+func TestNoRetryOnPermanentError(t *testing.T) {
+	ctx := context.Background()
+	errBad := errors.New("bad record")
+	for _, err := range []error{errBad, sharedlog.ErrNotLeader, context.Canceled} {
+		inner := &flakyLog{MemoryLog: memorylog.NewMemoryLog(), failures: 1, err: err}
+		l := NewRetryLog(inner, testOptions())
+		if _, got := l.AppendData(ctx, sharedlog.DataRecord{Key: "k"}); !errors.Is(got, err) {
+			t.Fatalf("err = %v, want %v", got, err)
+		}
+		if inner.attempts != 1 {
+			t.Fatalf("%v: %d attempts, want 1", err, inner.attempts)
+		}
+	}
+}
+
+func TestGiveUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	opts := testOptions()
+	inner := &flakyLog{MemoryLog: memorylog.NewMemoryLog(), failures: 100, err: errUnavailable}
+	l := NewRetryLog(inner, opts)
+	start := time.Now()
+	if _, err := l.AppendData(ctx, sharedlog.DataRecord{Key: "k"}); !errors.Is(err, errUnavailable) {
+		t.Fatalf("err = %v, want the last append error", err)
+	}
+	if inner.attempts != opts.MaxAttempts {
+		t.Fatalf("%d attempts, want MaxAttempts = %d", inner.attempts, opts.MaxAttempts)
+	}
+	// 5ms, then 10ms twice: the second doubling is capped by MaxBackoff
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Fatalf("gave up after %v, want at least the 25ms of backoff", elapsed)
+	}
+
+	// a cancelled context cuts the backoff short
+	inner.attempts = 0
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := l.AppendData(cctx, sharedlog.DataRecord{Key: "k"}); err == nil {
+		t.Fatal("append with a cancelled context succeeded")
+	}
+	if inner.attempts != 1 {
+		t.Fatalf("%d attempts with a cancelled context, want 1", inner.attempts)
+	}
+}
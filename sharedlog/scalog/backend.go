@@ -0,0 +1,11 @@
+package scalog
+
+import "github.com/chn0318/logstore/sharedlog"
+
+// The "scalog" backend reads the Scalog cluster layout from the global viper
+// config, as the Scalog client library does.
+func init() {
+	sharedlog.RegisterBackend("scalog", func(sharedlog.Config, sharedlog.Hooks) (sharedlog.SharedLog, error) {
+		return NewScalogSystem()
+	})
+}
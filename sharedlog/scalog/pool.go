@@ -0,0 +1,208 @@
+package scalog
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chn0318/scalog/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrClosed          = errors.New("scalog: closed")
+	ErrNoHealthyClient = errors.New("scalog: no healthy client in the pool")
+)
+
+// Backoff between attempts to recreate a client.
+const (
+	reconnectMinBackoff = 100 * time.Millisecond
+	reconnectMaxBackoff = 5 * time.Second
+)
+
+// PoolStats describes the client pool.
+type PoolStats struct {
+	Clients int
+	Healthy int
+	// Failures counts calls that failed with a connection error; each one
+	// takes its client out of rotation.
+	Failures   uint64
+	Reconnects uint64
+}
+
+// pool round-robins over the healthy Scalog clients. A client that fails
+// with a connection error is dropped and a new one is created in the
+// background.
+//
+// client.Client has no Close, so a dropped client's discovery stream stays
+// open until the process exits. To avoid leaking clients when the cluster
+// is unreachable at startup, only the first client is created up front.
+type pool struct {
+	newClient func() (*client.Client, error)
+
+	mu       sync.Mutex
+	slots    []slot
+	next     int
+	closed   bool
+	counters PoolStats // Failures and Reconnects
+	stopC    chan struct{}
+}
+
+type slot struct {
+	c *client.Client // nil while (re)connecting
+	// gen changes whenever c is replaced, so late reports about an old
+	// client are ignored
+	gen uint64
+}
+
+// member is a client handed out by pick.
+type member struct {
+	c    *client.Client
+	slot int
+	gen  uint64
+}
+
+func newPool(size int, newClient func() (*client.Client, error)) (*pool, error) {
+	first, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	p := &pool{
+		newClient: newClient,
+		slots:     make([]slot, size),
+		stopC:     make(chan struct{}),
+	}
+	p.slots[0].c = first
+	for i := 1; i < size; i++ {
+		go p.reconnect(i, 0)
+	}
+	return p, nil
+}
+
+func (p *pool) pick() (member, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return member{}, ErrClosed
+	}
+	for range p.slots {
+		i := p.next
+		p.next = (p.next + 1) % len(p.slots)
+		if c := p.slots[i].c; c != nil {
+			return member{c: c, slot: i, gen: p.slots[i].gen}, nil
+		}
+	}
+	return member{}, ErrNoHealthyClient
+}
+
+// healthy returns the number of clients in rotation.
+func (p *pool) healthy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, s := range p.slots {
+		if s.c != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// report takes m out of rotation if err is a connection error.
+func (p *pool) report(m member, err error) {
+	if !isConnErr(err) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := &p.slots[m.slot]
+	if p.closed || s.gen != m.gen || s.c == nil {
+		return
+	}
+	s.c = nil
+	s.gen++
+	p.counters.Failures++
+	log.Printf("scalog: client %d failed, reconnecting: %v", m.slot, err)
+	go p.reconnect(m.slot, s.gen)
+}
+
+// reconnect creates a client for slot i until it succeeds or the pool is
+// closed. client.NewClient blocks while the discovery server is down, so
+// Close does not wait for it.
+func (p *pool) reconnect(i int, gen uint64) {
+	backoff := reconnectMinBackoff
+	for {
+		c, err := p.newClient()
+		if err == nil {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.closed || p.slots[i].gen != gen {
+				return
+			}
+			p.slots[i].c = c
+			p.slots[i].gen++
+			if gen > 0 {
+				p.counters.Reconnects++
+				log.Printf("scalog: client %d reconnected", i)
+			}
+			return
+		}
+		log.Printf("scalog: creating client %d: %v", i, err)
+		select {
+		case <-p.stopC:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > reconnectMaxBackoff {
+			backoff = reconnectMaxBackoff
+		}
+	}
+}
+
+func (p *pool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.counters
+	st.Clients = len(p.slots)
+	for _, s := range p.slots {
+		if s.c != nil {
+			st.Healthy++
+		}
+	}
+	return st
+}
+
+func (p *pool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.closed = true
+	close(p.stopC)
+	for i := range p.slots {
+		p.slots[i].c = nil
+	}
+	return nil
+}
+
+// connError wraps an error that left the pool unable to reach the cluster.
+// It is temporary: a retry may be served by another or a reconnected client.
+type connError struct{ err error }
+
+func (e *connError) Error() string   { return e.err.Error() }
+func (e *connError) Unwrap() error   { return e.err }
+func (e *connError) Temporary() bool { return true }
+
+// isConnErr reports whether err means the client cannot reach the cluster.
+// The client returns plain errors when dialing fails and gRPC statuses from
+// calls.
+func isConnErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	st, ok := status.FromError(err)
+	return !ok || st.Code() == codes.Unavailable
+}
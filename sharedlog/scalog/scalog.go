@@ -1,8 +1,11 @@
 package scalog
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/chn0318/logstore/sharedlog"
 	"github.com/chn0318/scalog/client"
@@ -10,11 +13,20 @@ import (
 	"github.com/spf13/viper"
 )
 
+// ScalogSystem does not implement sharedlog.RecordScanner: a record the
+// data server accepted before the connection dropped can be ordered after
+// any scan, so a failed append cannot be settled by looking at the log.
+var _ sharedlog.SharedLog = (*ScalogSystem)(nil)
+
 type ScalogSystem struct {
-	clients []*client.Client
+	pool *pool
+	// shards 是配置里出现的所有数据分片，按 GSN 扫描日志时需要逐个分片去读
+	shards []int32
 
-	mu   sync.Mutex
-	next int
+	mu sync.Mutex
+	// tail 是本进程观察到的最大 GSN；hasTail 区分“还没见过任何记录”和 GSN=0
+	tail    uint64
+	hasTail bool
 }
 
 func NewScalogSystem() (*ScalogSystem, error) {
@@ -29,76 +41,279 @@ func NewScalogSystem() (*ScalogSystem, error) {
 		numClients = 4
 	}
 
-	clients := make([]*client.Client, 0, numClients)
-	for i := 0; i < numClients; i++ {
-		c, err := client.NewClient(dataAddr, discAddr, numReplica)
-		if err != nil {
-			// 如果你愿意，也可以在这里把已经创建的 client 依次 Close 掉
-			return nil, err
-		}
-		clients = append(clients, c)
+	p, err := newPool(numClients, func() (*client.Client, error) {
+		return client.NewClient(dataAddr, discAddr, numReplica)
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return &ScalogSystem{
-		clients: clients,
+		pool:   p,
+		shards: configuredShards(),
 	}, nil
 }
 
-func (s *ScalogSystem) pickClient() *client.Client {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	c := s.clients[s.next]
-	s.next = (s.next + 1) % len(s.clients)
-	return c
+// configuredShards lists the shard IDs present in the config, i.e. every sid
+// for which data-<sid>-0-ip is set.
+func configuredShards() []int32 {
+	shards := make([]int32, 0)
+	for sid := int32(0); viper.IsSet(fmt.Sprintf("data-%v-0-ip", sid)); sid++ {
+		shards = append(shards, sid)
+	}
+	return shards
 }
 
-func (s *ScalogSystem) AppendData(rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
-	data, err := json.Marshal(rec)
+// Stats describes the client pool.
+func (s *ScalogSystem) Stats() PoolStats { return s.pool.stats() }
+
+// Close drops the client pool; calls made afterwards return ErrClosed.
+func (s *ScalogSystem) Close() error { return s.pool.close() }
+
+// call runs fn on a pooled client and reports the outcome to the pool. fn
+// cannot be interrupted; if ctx is done first, call returns early and the
+// outcome is still reported when fn finishes.
+func call[T any](ctx context.Context, s *ScalogSystem, fn func(c *client.Client) (T, error)) (T, error) {
+	m, err := s.pool.pick()
+	if errors.Is(err, ErrNoHealthyClient) {
+		err = &connError{err}
+	}
 	if err != nil {
-		return sharedlog.RecordRef{}, err
+		var zero T
+		return zero, err
 	}
+	return abandonable(ctx, func() (T, error) {
+		v, err := fn(m.c)
+		s.pool.report(m, err)
+		if isConnErr(err) {
+			err = &connError{err}
+		}
+		return v, err
+	})
+}
 
-	c := s.pickClient()
-
-	gsn, sid, err := c.AppendOne(string(data))
+func (s *ScalogSystem) AppendData(ctx context.Context, rec sharedlog.DataRecord) (sharedlog.RecordRef, error) {
+	data, err := sharedlog.EncodeData(rec)
 	if err != nil {
 		return sharedlog.RecordRef{}, err
 	}
 
-	return sharedlog.RecordRef{
-		GSN:     uint64(gsn),
-		ShardID: uint32(sid),
-	}, nil
+	return s.appendOne(ctx, string(data))
 }
 
-func (s *ScalogSystem) AppendCommit(rec sharedlog.CommitRecord) (uint64, error) {
-	data, err := json.Marshal(rec)
+func (s *ScalogSystem) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	data, err := sharedlog.EncodeCommit(rec)
 	if err != nil {
 		return 0, err
 	}
 
-	c := s.pickClient()
-
-	gsn, _, err := c.AppendOne(string(data))
+	ref, err := s.appendOne(ctx, string(data))
 	if err != nil {
 		return 0, err
 	}
-	return uint64(gsn), nil
+	return ref.GSN, nil
 }
 
-func (s *ScalogSystem) ReadData(ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
-	rid := int32(0)
-	c := s.pickClient()
+func (s *ScalogSystem) appendOne(ctx context.Context, data string) (sharedlog.RecordRef, error) {
+	return call(ctx, s, func(c *client.Client) (sharedlog.RecordRef, error) {
+		gsn, sid, err := c.AppendOne(data)
+		if err != nil {
+			return sharedlog.RecordRef{}, err
+		}
+		// observe even if the caller gave up: the record is in the log
+		s.observe(uint64(gsn))
+		return sharedlog.RecordRef{GSN: uint64(gsn), ShardID: uint32(sid)}, nil
+	})
+}
+
+// abandonable runs fn, which cannot be interrupted, and returns early with
+// ctx.Err() if ctx is done first. fn keeps running in the background and its
+// result is dropped.
+func abandonable[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	resC := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		resC <- result{v, err}
+	}()
+	select {
+	case r := <-resC:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (s *ScalogSystem) AppendDataBatch(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	return s.AppendBatch(ctx, sharedlog.DataRecords(recs))
+}
+
+// AppendBatch pipelines the appends over the client pool: one worker per
+// healthy client, each keeping one append in flight. Refs are in input order but the
+// GSNs are not necessarily increasing. The records land independently, so a
+// failure is reported per record with a *sharedlog.BatchError.
+func (s *ScalogSystem) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	payloads := make([]string, len(recs))
+	for i, rec := range recs {
+		data, err := sharedlog.EncodeRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = string(data)
+	}
+
+	refs := make([]sharedlog.RecordRef, len(recs))
+	errs := make([]error, len(recs))
+	idxC := make(chan int)
+	workers := s.pool.healthy()
+	if workers > len(recs) {
+		workers = len(recs)
+	}
+	if workers == 0 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxC {
+				refs[i], errs[i] = s.appendOne(ctx, payloads[i])
+			}
+		}()
+	}
+	for i := range payloads {
+		idxC <- i
+	}
+	close(idxC)
+	wg.Wait()
+	return sharedlog.BatchResult(refs, errs)
+}
 
-	data, err := c.Read(int64(ref.GSN), int32(ref.ShardID), rid)
+func (s *ScalogSystem) ReadData(ctx context.Context, ref sharedlog.RecordRef) (sharedlog.DataRecord, error) {
+	rid := int32(0)
+	data, err := call(ctx, s, func(c *client.Client) (string, error) {
+		return c.Read(int64(ref.GSN), int32(ref.ShardID), rid)
+	})
 	if err != nil {
 		return sharedlog.DataRecord{}, err
 	}
 
-	var rec sharedlog.DataRecord
-	if err := json.Unmarshal([]byte(data), &rec); err != nil {
-		return sharedlog.DataRecord{}, err
+	return sharedlog.DecodeData([]byte(data))
+}
+
+// ReplayCommits scans [from, to] in GSN order. Scalog does not tell us which
+// shard holds a given GSN, so every shard is probed and DATA records are
+// skipped.
+func (s *ScalogSystem) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	if tail := s.Tail(ctx); to > tail {
+		to = tail
+	}
+	for gsn := from; gsn <= to; gsn++ {
+		data, _, found, err := s.readAny(ctx, gsn)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		rec, err := sharedlog.DecodeCommit([]byte(data))
+		if errors.Is(err, sharedlog.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gsn=%d: %w", gsn, err)
+		}
+		if err := handler(gsn, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe polls the shards. Until some GSN has been seen Tail cannot tell
+// an empty log from one holding only GSN 0, so polling starts after that.
+func (s *ScalogSystem) Subscribe(ctx context.Context, from uint64, handler func(uint64, sharedlog.CommitRecord) error) error {
+	for {
+		s.Tail(ctx)
+		s.mu.Lock()
+		hasTail := s.hasTail
+		s.mu.Unlock()
+		if hasTail {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sharedlog.DefaultPollInterval):
+		}
+	}
+	return sharedlog.PollCommits(ctx, s, from, sharedlog.DefaultPollInterval, handler)
+}
+
+// Head returns the first GSN of the log. Scalog never trims, so this is
+// always the order layer's starting GSN.
+func (s *ScalogSystem) Head(ctx context.Context) uint64 { return 0 }
+
+// Tail returns the largest GSN in the log. Other clients may have appended
+// since we last looked, so it probes forward from the largest GSN this
+// process has seen until a GSN is found in no shard.
+func (s *ScalogSystem) Tail(ctx context.Context) uint64 {
+	s.mu.Lock()
+	next, hasTail := s.tail+1, s.hasTail
+	s.mu.Unlock()
+	if !hasTail {
+		next = s.Head(ctx)
+	}
+	for {
+		_, _, found, err := s.readAny(ctx, next)
+		if err != nil || !found {
+			break
+		}
+		s.observe(next)
+		next++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tail
+}
+
+// Trim is not supported: the Scalog data servers expose no trim RPC.
+func (s *ScalogSystem) Trim(ctx context.Context, upTo uint64) error {
+	return sharedlog.ErrTrimNotSupported
+}
+
+func (s *ScalogSystem) observe(gsn uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hasTail || gsn > s.tail {
+		s.tail = gsn
+		s.hasTail = true
+	}
+}
+
+// readAny looks up gsn in every shard. The data server answers an empty
+// record (and no error) for GSNs it does not own.
+func (s *ScalogSystem) readAny(ctx context.Context, gsn uint64) (string, uint32, bool, error) {
+	rid := int32(0)
+	for _, sid := range s.shards {
+		data, err := call(ctx, s, func(c *client.Client) (string, error) {
+			return c.Read(int64(gsn), sid, rid)
+		})
+		if err != nil {
+			return "", 0, false, err
+		}
+		if data != "" {
+			return data, uint32(sid), true, nil
+		}
 	}
-	return rec, nil
+	return "", 0, false, nil
 }
@@ -1,44 +1,193 @@
 package sharedlog
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
 // DataRecord represents a key-value write operation stored in the log.
 type DataRecord struct {
 	Key   string
 	Value []byte
+	// TxnID optionally ties the record to a transaction. It is carried in
+	// the envelope rather than in the payload.
+	TxnID string `json:"-"`
+	// Token optionally identifies this append so that a retry can find it
+	// in the log (see retrylog). Also carried in the envelope.
+	Token string `json:"-"`
 }
 
 // CommitEntry links a key to its corresponding DataRecord's GSN.
+// A Tombstone entry deletes the key and has no DataRecord; Ref is zero.
 type CommitEntry struct {
-	Key string
-	Ref RecordRef
+	Key       string
+	Ref       RecordRef
+	Tombstone bool `json:",omitempty"`
 }
 
 // CommitRecord represents a multi-key atomic transaction commit.
 type CommitRecord struct {
 	Entries []CommitEntry
+	TxnID   string `json:"-"`
+	Token   string `json:"-"`
+	// ClientID and Seq identify the client request that produced the commit,
+	// so that the server can recognise a retry of it, also after a restart.
+	ClientID string `json:",omitempty"`
+	Seq      uint64 `json:",omitempty"`
 }
 
 // SharedLog defines the abstraction of an append-only shared log system.
 // Implementations can be backed by CORFU, Scalog, or other log-based systems.
+//
+// Every method takes a context. When it is done before the call completes the
+// method returns ctx.Err(); an append abandoned this way may still reach the
+// log, so its outcome is unknown to the caller.
 type SharedLog interface {
 	// AppendData appends a DATA record (key-value pair) to the shared log.
 	// Returns the assigned global sequence number (GSN).
-	AppendData(rec DataRecord) (ref RecordRef, err error)
+	AppendData(ctx context.Context, rec DataRecord) (ref RecordRef, err error)
 
 	// AppendCommit appends a COMMIT record to the log,
 	// referencing multiple DataRecords via their GSNs.
 	// Returns the commit record's own GSN.
-	AppendCommit(rec CommitRecord) (commitGSN uint64, err error)
+	AppendCommit(ctx context.Context, rec CommitRecord) (commitGSN uint64, err error)
+
+	// AppendDataBatch appends several DATA records in one call and returns
+	// their refs in input order. On error some of the records may already
+	// be in the log; a *BatchError tells which, and the others must be
+	// treated as garbage.
+	AppendDataBatch(ctx context.Context, recs []DataRecord) ([]RecordRef, error)
+
+	// AppendBatch is AppendDataBatch for a mix of DATA and COMMIT records.
+	// Each Record must have Type set and the matching Data or Commit field.
+	// For COMMIT records only the GSN of the returned ref is meaningful.
+	// Backends that append the records independently return a *BatchError
+	// when only some of them failed, together with refs for the others.
+	AppendBatch(ctx context.Context, recs []Record) ([]RecordRef, error)
 
 	// ReadData retrieves a DATA record by its GSN.
-	ReadData(ref RecordRef) (DataRecord, error)
+	ReadData(ctx context.Context, ref RecordRef) (DataRecord, error)
+
+	// ReplayCommits replays COMMIT records in GSN order from [fromGSN, toGSN].
+	// The provided handler is called for each commit record.
+	ReplayCommits(ctx context.Context, fromGSN, toGSN uint64, handler func(commitGSN uint64, rec CommitRecord) error) error
+
+	// Subscribe calls handler for every COMMIT record with a GSN >= fromGSN
+	// in GSN order, first those already in the log and then new ones as
+	// they are appended. fromGSN 0 starts at Head(). It blocks until ctx is
+	// done or handler fails and returns that error, or ErrTrimmed if the
+	// records it still has to deliver were trimmed.
+	Subscribe(ctx context.Context, fromGSN uint64, handler func(commitGSN uint64, rec CommitRecord) error) error
+
+	// Head returns the smallest GSN currently available (useful for log trimming).
+	Head(ctx context.Context) uint64
+
+	// Tail returns the largest GSN written so far.
+	Tail(ctx context.Context) uint64
 
-	// // ReplayCommits replays COMMIT records in GSN order from [fromGSN, toGSN].
-	// // The provided handler is called for each commit record.
-	// ReplayCommits(fromGSN, toGSN uint64, handler func(commitGSN uint64, rec CommitRecord) error) error
+	// Trim discards records with a GSN < upTo. A backend may trim at a
+	// coarser granularity and keep some of them, so Head() can stay below
+	// upTo. Backends that cannot trim return ErrTrimNotSupported.
+	Trim(ctx context.Context, upTo uint64) error
+
+	// Close releases the backend's resources. The log must not be used
+	// afterwards.
+	Close() error
+}
+
+// RecordScanner is implemented by backends that can read back every record
+// by GSN, DATA as well as COMMIT, and whose failed appends are settled by a
+// scan: once ScanRecords(from, Tail()) has returned, a record of an append
+// that had already failed either was in the scan or will never reach the
+// log. retrylog relies on this to retry appends without duplicating them;
+// backends that may still order a rejected record later must not implement
+// it.
+type RecordScanner interface {
+	// ScanRecords calls fn for each record in [fromGSN, toGSN] in GSN order.
+	// Positions below Head() or holding no record are skipped.
+	ScanRecords(ctx context.Context, fromGSN, toGSN uint64, fn func(ref RecordRef, rec Record) error) error
+}
+
+// BoundsReader is implemented by backends whose Head and Tail may have to
+// ask a remote service. When that fails Head and Tail fall back to the last
+// known value; Bounds reports the failure instead.
+type BoundsReader interface {
+	// Bounds returns Head() and Tail(), or the error that kept it from
+	// determining them.
+	Bounds(ctx context.Context) (head, tail uint64, err error)
+}
 
-	// // Head returns the smallest GSN currently available (useful for log trimming).
-	// Head() uint64
+// Bounds returns the head and tail of l. If l is a BoundsReader it fails
+// rather than return values that may be stale.
+func Bounds(ctx context.Context, l SharedLog) (head, tail uint64, err error) {
+	if b, ok := l.(BoundsReader); ok {
+		return b.Bounds(ctx)
+	}
+	return l.Head(ctx), l.Tail(ctx), nil
+}
+
+// ErrTrimNotSupported is returned by Trim on backends that never discard
+// records.
+var ErrTrimNotSupported = errors.New("sharedlog: trim not supported")
+
+// ErrNotLeader is returned by replicated backends when this replica cannot
+// accept appends or trims right now; another replica is (or will become)
+// the leader.
+var ErrNotLeader = errors.New("sharedlog: not the leader")
+
+// ErrTrimmed is returned by Subscribe when the records it would deliver next
+// are below Head().
+var ErrTrimmed = errors.New("sharedlog: records trimmed")
+
+// BatchError is returned by AppendBatch, along with a ref for every record,
+// when the records did not all succeed or fail together. Errs[i] is nil if
+// record i is in the log at refs[i], and otherwise what a failed single
+// append of it would have returned.
+type BatchError struct {
+	Errs []error
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Unwrap()
+	if len(failed) == 0 {
+		return "sharedlog: batch append: no error"
+	}
+	return fmt.Sprintf("sharedlog: %d of %d records not appended: %v", len(failed), len(e.Errs), failed[0])
+}
+
+// Unwrap returns the errors of the failed records.
+func (e *BatchError) Unwrap() []error {
+	var failed []error
+	for _, err := range e.Errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return failed
+}
+
+// RecordErrors returns the error of each of the n records of an AppendBatch
+// that returned err: BatchError's per-record errors, or err for all of them.
+func RecordErrors(n int, err error) []error {
+	var be *BatchError
+	if errors.As(err, &be) && len(be.Errs) == n {
+		return be.Errs
+	}
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
 
-	// // Tail returns the largest GSN written so far.
-	// Tail() uint64
+// BatchResult is what AppendBatch returns for records appended
+// independently with the given refs and per-record errors.
+func BatchResult(refs []RecordRef, errs []error) ([]RecordRef, error) {
+	for _, err := range errs {
+		if err != nil {
+			return refs, &BatchError{Errs: errs}
+		}
+	}
+	return refs, nil
 }
@@ -0,0 +1,65 @@
+package sharedlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultPollInterval is how often PollCommits looks for new records.
+const DefaultPollInterval = 10 * time.Millisecond
+
+// PollCommits implements Subscribe for backends that cannot notify about
+// appends: it replays [next, Tail()] every interval.
+func PollCommits(ctx context.Context, l SharedLog, fromGSN uint64, interval time.Duration, handler func(commitGSN uint64, rec CommitRecord) error) error {
+	next := fromGSN
+	if next == 0 {
+		next = l.Head(ctx)
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if head := l.Head(ctx); next < head {
+			return fmt.Errorf("%w: gsn %d is below head %d", ErrTrimmed, next, head)
+		}
+		if tail := l.Tail(ctx); tail >= next {
+			if err := l.ReplayCommits(ctx, next, tail, handler); err != nil {
+				return err
+			}
+			next = tail + 1
+		}
+		timer.Reset(interval)
+	}
+}
+
+// NextGSN returns the GSN from which Subscribe delivers only commits that
+// are not in l yet. Tail()+1 is not it on a backend whose first GSN is 0:
+// there an empty log and one holding only GSN 0 both have Tail() == Head(),
+// so the record at Head() is looked up. A DATA record there is never
+// delivered, so starting at it is fine.
+func NextGSN(ctx context.Context, l SharedLog) (uint64, error) {
+	head, tail := l.Head(ctx), l.Tail(ctx)
+	if tail > head {
+		return tail + 1, nil
+	}
+	if tail < head {
+		return head, nil
+	}
+	found := false
+	err := l.ReplayCommits(ctx, head, head, func(uint64, CommitRecord) error {
+		found = true
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		return head + 1, nil
+	}
+	return head, nil
+}
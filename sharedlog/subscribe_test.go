@@ -0,0 +1,53 @@
+package sharedlog
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeLog holds records at GSNs head..tail; commits lists the COMMIT ones.
+type fakeLog struct {
+	SharedLog
+	head, tail uint64
+	commits    map[uint64]bool
+}
+
+func (l *fakeLog) Head(context.Context) uint64 { return l.head }
+func (l *fakeLog) Tail(context.Context) uint64 { return l.tail }
+
+func (l *fakeLog) ReplayCommits(ctx context.Context, from, to uint64, handler func(uint64, CommitRecord) error) error {
+	for gsn := from; gsn <= to; gsn++ {
+		if l.commits[gsn] {
+			if err := handler(gsn, CommitRecord{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestNextGSN(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		log  fakeLog
+		want uint64
+	}{
+		// an empty log and one holding only a DATA record at 0 look the same
+		{"empty or data at GSN 0", fakeLog{}, 0},
+		{"commit at GSN 0", fakeLog{commits: map[uint64]bool{0: true}}, 1},
+		{"empty, first GSN 1", fakeLog{head: 1}, 1},
+		{"one record", fakeLog{head: 1, tail: 1, commits: map[uint64]bool{1: true}}, 2},
+		{"several records", fakeLog{head: 1, tail: 5}, 6},
+		{"trimmed", fakeLog{head: 4, tail: 3}, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NextGSN(context.Background(), &tc.log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("NextGSN = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package storageserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/chn0318/logstore/recovery"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// waitApplied blocks until every commit up to gsn, made by this server or
+// another one, has been applied, for at most Options.MaxReadWait. Commits
+// of other servers sharing the log reach the map through the catch-up.
+func (s *StorageServer) waitApplied(ctx context.Context, gsn uint64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.MaxReadWait)
+	defer cancel()
+	for {
+		done, err := recovery.CatchUpTo(ctx, s.sharedLog, s.mapService, gsn)
+		if err != nil {
+			return err
+		}
+		if done {
+			// 本 server 自己还在途的 commit 由 WaitStable 等
+			return s.mapService.WaitStable(ctx, gsn)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sharedlog.DefaultPollInterval):
+		}
+	}
+}
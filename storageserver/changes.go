@@ -0,0 +1,99 @@
+package storageserver
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// Changes streams every commit in the log from the cursor on, with the
+// values resolved, for consumers that mirror the store elsewhere. Unlike
+// Watch it follows the log itself, so it does not depend on this server
+// having applied the commits.
+func (s *StorageServer) Changes(req *storagepb.ChangesRequest, stream grpc.ServerStreamingServer[storagepb.ChangeEvent]) error {
+	ctx := stream.Context()
+	var from uint64
+	if req.Cursor != "" {
+		last, err := decodeChangeCursor(req.Cursor)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "bad cursor: %v", err)
+		}
+		from = last + 1
+	} else {
+		next, err := sharedlog.NextGSN(ctx, s.sharedLog)
+		if err != nil {
+			return rpcError(err)
+		}
+		from = next
+	}
+
+	err := s.sharedLog.Subscribe(ctx, from, func(gsn uint64, rec sharedlog.CommitRecord) error {
+		var refs []sharedlog.RecordRef
+		for _, e := range rec.Entries {
+			if !e.Tombstone {
+				refs = append(refs, e.Ref)
+			}
+		}
+		values, err := s.readValues(ctx, refs)
+		if err != nil {
+			// 旧 commit 引用的 DATA 可能已经被 GC trim 掉了
+			if head := s.sharedLog.Head(ctx); minGSN(refs) < head {
+				return status.Errorf(codes.OutOfRange, "commit %d references data trimmed from the log (head %d)", gsn, head)
+			}
+			return err
+		}
+		ev := &storagepb.ChangeEvent{
+			CommitGsn: gsn,
+			TxnId:     rec.TxnID,
+			Kvs:       make([]*storagepb.KV, 0, len(rec.Entries)),
+			Cursor:    encodeChangeCursor(gsn),
+		}
+		for _, e := range rec.Entries {
+			if e.Tombstone {
+				ev.Kvs = append(ev.Kvs, &storagepb.KV{Key: e.Key, Delete: true})
+				continue
+			}
+			ev.Kvs = append(ev.Kvs, &storagepb.KV{Key: e.Key, Value: values[0]})
+			values = values[1:]
+		}
+		return stream.Send(ev)
+	})
+	if errors.Is(err, sharedlog.ErrTrimmed) {
+		return status.Errorf(codes.OutOfRange, "cursor is behind the log: %v", err)
+	}
+	return rpcError(err)
+}
+
+func minGSN(refs []sharedlog.RecordRef) uint64 {
+	var min uint64
+	for i, ref := range refs {
+		if i == 0 || ref.GSN < min {
+			min = ref.GSN
+		}
+	}
+	return min
+}
+
+func encodeChangeCursor(commitGSN uint64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], commitGSN)
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+func decodeChangeCursor(cursor string) (uint64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 8 {
+		return 0, errors.New("wrong length")
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
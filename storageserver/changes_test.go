@@ -0,0 +1,79 @@
+package storageserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/storagepb"
+)
+
+func changes(t *testing.T, s *StorageServer, cursor string) (<-chan *storagepb.ChangeEvent, <-chan error) {
+	t.Helper()
+	req := &storagepb.ChangesRequest{Cursor: cursor}
+	return stream(t, func(st grpc.ServerStreamingServer[storagepb.ChangeEvent]) error { return s.Changes(req, st) })
+}
+
+func TestChangesResume(t *testing.T) {
+	s := newServer()
+	g1 := write(t, s, "a", "1")
+	g2 := write(t, s, "b", "2")
+	if _, err := s.MultiDelete(context.Background(), &storagepb.MultiDeleteRequest{Keys: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := changes(t, s, encodeChangeCursor(g1))
+	ev := next(t, c)
+	if ev.CommitGsn != g2 || len(ev.Kvs) != 1 || ev.Kvs[0].Key != "b" || string(ev.Kvs[0].Value) != "2" {
+		t.Fatalf("first event = %v, want b=2 at %d", ev, g2)
+	}
+	del := next(t, c)
+	if len(del.Kvs) != 1 || !del.Kvs[0].Delete || del.Kvs[0].Key != "a" {
+		t.Fatalf("second event = %v, want the delete of a", del)
+	}
+	live := write(t, s, "c", "3")
+	if ev := next(t, c); ev.CommitGsn != live {
+		t.Fatalf("live event at %d, want %d", ev.CommitGsn, live)
+	}
+
+	// a consumer that processed the first event resumes right after it
+	resumed, _ := changes(t, s, ev.Cursor)
+	if ev := next(t, resumed); ev.CommitGsn != del.CommitGsn {
+		t.Fatalf("resumed feed starts at %d, want %d", ev.CommitGsn, del.CommitGsn)
+	}
+}
+
+func TestChangesBadCursor(t *testing.T) {
+	ctx := context.Background()
+	s := newServer()
+	g1 := write(t, s, "a", "1")
+	write(t, s, "a", "2")
+	if err := s.sharedLog.Trim(ctx, g1+1); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		cursor string
+		code   codes.Code
+	}{
+		{"malformed", "%%", codes.InvalidArgument},
+		{"wrong length", "AAAA", codes.InvalidArgument},
+		{"trimmed", encodeChangeCursor(g1 - 1), codes.OutOfRange},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errC := changes(t, s, tc.cursor)
+			select {
+			case err := <-errC:
+				if status.Code(err) != tc.code {
+					t.Fatalf("err = %v, want %v", err, tc.code)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("feed from a bad cursor did not fail")
+			}
+		})
+	}
+}
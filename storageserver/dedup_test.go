@@ -0,0 +1,163 @@
+package storageserver
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/recovery"
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+func put(client string, seq uint64, key, value string) *storagepb.MultiPutRequest {
+	return &storagepb.MultiPutRequest{
+		Kvs:      []*storagepb.KV{{Key: key, Value: []byte(value)}},
+		ClientId: client,
+		Seq:      seq,
+	}
+}
+
+func countCommits(t *testing.T, l sharedlog.SharedLog) int {
+	t.Helper()
+	ctx := context.Background()
+	n := 0
+	err := l.ReplayCommits(ctx, l.Head(ctx), l.Tail(ctx), func(uint64, sharedlog.CommitRecord) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestMultiPutDedup(t *testing.T) {
+	ctx := context.Background()
+	l := memorylog.NewMemoryLog()
+	s := NewStorageServer(l, mapservice.NewMapService(), DefaultOptions())
+
+	first, err := s.MultiPut(ctx, put("c", 1, "k", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a retry returns the original outcome and commits nothing
+	retry, err := s.MultiPut(ctx, put("c", 1, "k", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry.CommitGsn != first.CommitGsn || retry.Refs["k"].GetGsn() != first.Refs["k"].GetGsn() {
+		t.Fatalf("retry = %v, want the outcome of %v", retry, first)
+	}
+	if n := countCommits(t, l); n != 1 {
+		t.Fatalf("%d commits in the log, want 1", n)
+	}
+
+	second, err := s.MultiPut(ctx, put("c", 2, "k", "v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.CommitGsn <= first.CommitGsn {
+		t.Fatalf("seq 2 committed at %d, not after %d", second.CommitGsn, first.CommitGsn)
+	}
+
+	// a request older than the last committed one is rejected
+	if _, err := s.MultiPut(ctx, put("c", 1, "k", "stale")); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("out-of-order seq: err = %v, want FailedPrecondition", err)
+	}
+	// seq must be set with a client ID
+	if _, err := s.MultiPut(ctx, put("c", 0, "k", "v")); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("seq 0: err = %v, want InvalidArgument", err)
+	}
+	// other clients are independent
+	if _, err := s.MultiPut(ctx, put("d", 1, "k", "v3")); err != nil {
+		t.Fatal(err)
+	}
+	if n := countCommits(t, l); n != 3 {
+		t.Fatalf("%d commits in the log, want 3", n)
+	}
+
+	// a server recovered from the log still knows the sessions
+	ms := mapservice.NewMapService()
+	if _, err := recovery.Replay(ctx, l, ms, l.Head(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	recovered := NewStorageServer(l, ms, DefaultOptions())
+	again, err := recovered.MultiPut(ctx, put("c", 2, "k", "v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.CommitGsn != second.CommitGsn || again.Refs["k"].GetGsn() != second.Refs["k"].GetGsn() {
+		t.Fatalf("retry after recovery = %v, want the outcome of %v", again, second)
+	}
+	if _, err := recovered.MultiPut(ctx, put("c", 1, "k", "stale")); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("out-of-order seq after recovery: err = %v, want FailedPrecondition", err)
+	}
+	if n := countCommits(t, l); n != 3 {
+		t.Fatalf("%d commits in the log, want 3", n)
+	}
+}
+
+// lostReplyLog fails the next commit append after writing it, as when the
+// reply is lost on the way back.
+type lostReplyLog struct {
+	*memorylog.MemoryLog
+	lose bool
+}
+
+var errLostReply = status.Error(codes.Unavailable, "connection reset")
+
+func (l *lostReplyLog) AppendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	gsn, err := l.MemoryLog.AppendCommit(ctx, rec)
+	if err == nil && l.lose {
+		l.lose = false
+		return 0, errLostReply
+	}
+	return gsn, err
+}
+
+func (l *lostReplyLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	refs, err := l.MemoryLog.AppendBatch(ctx, recs)
+	if err == nil && l.lose && recs[0].Type == sharedlog.RecordTypeCommit {
+		l.lose = false
+		return nil, errLostReply
+	}
+	return refs, err
+}
+
+func TestMultiPutRetryAfterLostCommit(t *testing.T) {
+	ctx := context.Background()
+	l := &lostReplyLog{MemoryLog: memorylog.NewMemoryLog()}
+	s := NewStorageServer(l, mapservice.NewMapService(), DefaultOptions())
+
+	l.lose = true
+	if _, err := s.MultiPut(ctx, put("c", 1, "k", "v1")); err == nil {
+		t.Fatal("MultiPut with a lost commit reply succeeded")
+	}
+	if !s.mapService.NeedsReplay() {
+		t.Fatal("commit of unknown outcome does not need a replay")
+	}
+
+	// the retry finds the commit that landed instead of writing it again
+	retry, err := s.MultiPut(ctx, put("c", 1, "k", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countCommits(t, l); n != 1 {
+		t.Fatalf("%d commits in the log, want 1", n)
+	}
+	if tail := l.Tail(ctx); retry.CommitGsn != tail {
+		t.Fatalf("retry committed at %d, want the landed commit at %d", retry.CommitGsn, tail)
+	}
+	if s.mapService.NeedsReplay() {
+		t.Fatal("still needs a replay after the retry caught up")
+	}
+	if got := read(t, s, "k"); string(got.Values["k"]) != "v1" || got.CommitGsns["k"] != retry.CommitGsn {
+		t.Fatalf("k = %q at %d, want v1 at %d", got.Values["k"], got.CommitGsns["k"], retry.CommitGsn)
+	}
+}
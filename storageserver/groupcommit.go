@@ -0,0 +1,137 @@
+package storageserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// batcher coalesces submissions from concurrent requests into one flush.
+// A batch is flushed when it holds maxBatch items or maxDelay after its
+// first submission, whichever comes first. Flushes run concurrently with
+// the collection of the next batch.
+type batcher[T, R any] struct {
+	maxDelay time.Duration
+	maxBatch int
+	// flush handles one batch and returns a result and an error per item.
+	flush func(items []T) ([]R, []error)
+
+	reqC chan *batchReq[T, R]
+}
+
+type batchReq[T, R any] struct {
+	items   []T
+	results []R
+	err     error
+	done    chan struct{}
+}
+
+func newBatcher[T, R any](maxDelay time.Duration, maxBatch int, flush func([]T) ([]R, []error)) *batcher[T, R] {
+	b := &batcher[T, R]{
+		maxDelay: maxDelay,
+		maxBatch: maxBatch,
+		flush:    flush,
+		reqC:     make(chan *batchReq[T, R], maxBatch),
+	}
+	go b.run()
+	return b
+}
+
+// submit adds items to the current batch and waits for its flush. The
+// results are in the order of items; if any item failed, err is the first
+// failure and the request as a whole must be treated as failed. If ctx is
+// done first submit returns ctx.Err(), but items already queued are still
+// appended with the rest of their batch.
+func (b *batcher[T, R]) submit(ctx context.Context, items []T) ([]R, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	req := &batchReq[T, R]{items: items, done: make(chan struct{})}
+	select {
+	case b.reqC <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case <-req.done:
+		return req.results, req.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *batcher[T, R]) run() {
+	for first := range b.reqC {
+		batch := []*batchReq[T, R]{first}
+		n := len(first.items)
+		timer := time.NewTimer(b.maxDelay)
+	collect:
+		for n < b.maxBatch {
+			select {
+			case req := <-b.reqC:
+				batch = append(batch, req)
+				n += len(req.items)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		go b.flushBatch(batch, n)
+	}
+}
+
+func (b *batcher[T, R]) flushBatch(batch []*batchReq[T, R], n int) {
+	items := make([]T, 0, n)
+	for _, req := range batch {
+		items = append(items, req.items...)
+	}
+	results, errs := b.flush(items)
+
+	off := 0
+	for _, req := range batch {
+		k := len(req.items)
+		req.results = results[off : off+k]
+		for _, err := range errs[off : off+k] {
+			if err != nil {
+				req.err = err
+				break
+			}
+		}
+		off += k
+		close(req.done)
+	}
+}
+
+// groupCommitter batches the DATA and COMMIT appends of concurrent requests.
+// Every request still gets its own commit record and commit GSN, so the
+// all-or-nothing visibility of a MultiPut is unchanged. A batch is shared by
+// many requests, so it is appended without any one request's deadline.
+//
+// On backends that append the records of a batch independently, a batch can
+// partly fail; each request then gets the errors of its own records only
+// (see sharedlog.BatchError), so a commit that landed is not reported failed.
+type groupCommitter struct {
+	data   *batcher[sharedlog.DataRecord, sharedlog.RecordRef]
+	commit *batcher[sharedlog.CommitRecord, uint64]
+}
+
+func newGroupCommitter(l sharedlog.SharedLog, maxDelay time.Duration, maxBatch int) *groupCommitter {
+	return &groupCommitter{
+		data: newBatcher(maxDelay, maxBatch, func(recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, []error) {
+			refs, err := l.AppendDataBatch(context.Background(), recs)
+			if refs == nil {
+				refs = make([]sharedlog.RecordRef, len(recs))
+			}
+			return refs, sharedlog.RecordErrors(len(recs), err)
+		}),
+		commit: newBatcher(maxDelay, maxBatch, func(recs []sharedlog.CommitRecord) ([]uint64, []error) {
+			gsns := make([]uint64, len(recs))
+			refs, err := l.AppendBatch(context.Background(), sharedlog.CommitRecords(recs))
+			for i, ref := range refs {
+				gsns[i] = ref.GSN
+			}
+			return gsns, sharedlog.RecordErrors(len(recs), err)
+		}),
+	}
+}
@@ -0,0 +1,116 @@
+package storageserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chn0318/logstore/sharedlog"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+// tenfold returns a batcher that multiplies by 10 and records the size of
+// every flush in sizes.
+func tenfold(maxDelay time.Duration, sizes *[]int) *batcher[int, int] {
+	var mu sync.Mutex
+	return newBatcher(maxDelay, 4, func(items []int) ([]int, []error) {
+		mu.Lock()
+		*sizes = append(*sizes, len(items))
+		mu.Unlock()
+		results := make([]int, len(items))
+		for i, it := range items {
+			results[i] = it * 10
+		}
+		return results, make([]error, len(items))
+	})
+}
+
+func TestBatcher(t *testing.T) {
+	var sizes []int
+	b := tenfold(time.Second, &sizes)
+
+	// four concurrent requests fill a batch, well before maxDelay
+	start := time.Now()
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			got, err := b.submit(context.Background(), []int{r})
+			if err != nil || len(got) != 1 || got[0] != r*10 {
+				t.Errorf("request %d: results %v, %v", r, got, err)
+			}
+		}(r)
+	}
+	wg.Wait()
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("a full batch waited for maxDelay")
+	}
+	if len(sizes) != 1 || sizes[0] != 4 {
+		t.Fatalf("flushes of %v items, want one of 4", sizes)
+	}
+
+	// a lone request is flushed after maxDelay with its items in order
+	b = tenfold(10*time.Millisecond, &sizes)
+	got, err := b.submit(context.Background(), []int{1, 2, 3})
+	if err != nil || len(got) != 3 || got[0] != 10 || got[2] != 30 {
+		t.Fatalf("results %v, %v", got, err)
+	}
+}
+
+// partialLog fails the records of a batch that commit the key "bad" and
+// appends the others.
+type partialLog struct {
+	*memorylog.MemoryLog
+}
+
+var errBad = errors.New("bad record")
+
+func (l partialLog) AppendBatch(ctx context.Context, recs []sharedlog.Record) ([]sharedlog.RecordRef, error) {
+	refs := make([]sharedlog.RecordRef, len(recs))
+	errs := make([]error, len(recs))
+	for i, rec := range recs {
+		if rec.Commit != nil && rec.Commit.Entries[0].Key == "bad" {
+			errs[i] = errBad
+			continue
+		}
+		got, err := l.MemoryLog.AppendBatch(ctx, recs[i:i+1])
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = got[0]
+	}
+	return sharedlog.BatchResult(refs, errs)
+}
+
+func TestGroupCommitPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	l := partialLog{memorylog.NewMemoryLog()}
+	// both commits go in one batch of two
+	gc := newGroupCommitter(l, time.Second, 2)
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	for _, key := range []string{"good", "bad"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			gsns, err := gc.commit.submit(ctx, []sharedlog.CommitRecord{{Entries: []sharedlog.CommitEntry{{Key: key}}}})
+			if err == nil && gsns[0] == 0 {
+				err = errors.New("no GSN")
+			}
+			mu.Lock()
+			errs[key] = err
+			mu.Unlock()
+		}(key)
+	}
+	wg.Wait()
+	if errs["good"] != nil {
+		t.Fatalf("the landed commit failed: %v", errs["good"])
+	}
+	if !errors.Is(errs["bad"], errBad) {
+		t.Fatalf("the failed commit: err = %v, want errBad", errs["bad"])
+	}
+}
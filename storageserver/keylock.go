@@ -0,0 +1,45 @@
+package storageserver
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+const numKeyLockStripes = 256
+
+// keyLocks is a striped lock table. Commits lock every key they validate or
+// write so that no other commit on those keys can slip in between
+// validation and ApplyCommit.
+type keyLocks struct {
+	stripes [numKeyLockStripes]sync.Mutex
+}
+
+func stripeOf(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % numKeyLockStripes)
+}
+
+// lock acquires the stripes of keys in ascending order (so concurrent
+// callers cannot deadlock) and returns the matching unlock function.
+func (l *keyLocks) lock(keys []string) func() {
+	seen := make(map[int]bool, len(keys))
+	idx := make([]int, 0, len(keys))
+	for _, k := range keys {
+		i := stripeOf(k)
+		if !seen[i] {
+			seen[i] = true
+			idx = append(idx, i)
+		}
+	}
+	sort.Ints(idx)
+	for _, i := range idx {
+		l.stripes[i].Lock()
+	}
+	return func() {
+		for j := len(idx) - 1; j >= 0; j-- {
+			l.stripes[idx[j]].Unlock()
+		}
+	}
+}
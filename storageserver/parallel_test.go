@@ -0,0 +1,78 @@
+package storageserver
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachLimit(t *testing.T) {
+	for _, limit := range []int{0, 1, 3, 50} {
+		var inFlight, peak, calls atomic.Int32
+		err := forEach(context.Background(), 20, limit, func(ctx context.Context, i int) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			calls.Add(1)
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls.Load() != 20 {
+			t.Fatalf("limit %d: %d calls, want 20", limit, calls.Load())
+		}
+		want := int32(limit)
+		if want < 1 {
+			want = 1
+		}
+		if peak.Load() > want {
+			t.Fatalf("limit %d: %d calls in flight", limit, peak.Load())
+		}
+	}
+}
+
+func TestForEachError(t *testing.T) {
+	errBoom := errors.New("boom")
+	var calls, cancelled atomic.Int32
+	err := forEach(context.Background(), 100, 4, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		if i == 2 {
+			return errBoom
+		}
+		select {
+		case <-ctx.Done():
+			cancelled.Add(1)
+		case <-time.After(time.Second):
+		}
+		return nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("err = %v, want the failing call's", err)
+	}
+	// the calls in flight when i=2 failed are cancelled, no new ones start
+	if calls.Load() > 4 || cancelled.Load() != calls.Load()-1 {
+		t.Fatalf("%d calls, %d cancelled after the failure", calls.Load(), cancelled.Load())
+	}
+
+	// sequentially the calls after the failing one never run
+	calls.Store(0)
+	err = forEach(context.Background(), 10, 1, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		if i == 2 {
+			return errBoom
+		}
+		return nil
+	})
+	if !errors.Is(err, errBoom) || calls.Load() != 3 {
+		t.Fatalf("sequential: err = %v after %d calls, want errBoom after 3", err, calls.Load())
+	}
+}
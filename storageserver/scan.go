@@ -0,0 +1,87 @@
+package storageserver
+
+import (
+	"encoding/base64"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// scanBatchSize is how many keys are pulled from the map service and sent
+// per ScanResponse.
+const scanBatchSize = 128
+
+// Scan streams live keys and their values in key order. Each batch is read
+// from the map service under one read lock, so a scan is not a consistent
+// snapshot across batches.
+func (s *StorageServer) Scan(req *storagepb.ScanRequest, stream grpc.ServerStreamingServer[storagepb.ScanResponse]) error {
+	start, end := req.StartKey, req.EndKey
+	if req.Prefix != "" {
+		start, end = req.Prefix, mapservice.PrefixEnd(req.Prefix)
+	}
+	if req.ContinuationToken != "" {
+		last, err := decodeScanToken(req.ContinuationToken)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "bad continuation token: %v", err)
+		}
+		// 从上一次返回的最后一个 key 之后继续
+		start = last + "\x00"
+	}
+
+	ctx := stream.Context()
+	remaining := int(req.Limit)
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		n := scanBatchSize
+		if req.Limit > 0 && remaining < n {
+			n = remaining
+		}
+		items, more := s.mapService.Scan(start, end, n)
+
+		refs := make([]sharedlog.RecordRef, len(items))
+		for i, it := range items {
+			refs[i] = it.Meta.Ref
+		}
+		values, err := s.readValues(ctx, refs)
+		if err != nil {
+			return rpcError(err)
+		}
+		resp := &storagepb.ScanResponse{
+			Kvs: make([]*storagepb.KV, 0, len(items)),
+		}
+		for i, it := range items {
+			resp.Kvs = append(resp.Kvs, &storagepb.KV{Key: it.Key, Value: values[i]})
+		}
+		remaining -= len(items)
+
+		done := !more || (req.Limit > 0 && remaining == 0)
+		if done && more {
+			resp.ContinuationToken = encodeScanToken(items[len(items)-1].Key)
+		}
+		if len(resp.Kvs) > 0 || resp.ContinuationToken != "" {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+		start = items[len(items)-1].Key + "\x00"
+	}
+}
+
+func encodeScanToken(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func decodeScanToken(tok string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	return string(b), err
+}
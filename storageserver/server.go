@@ -2,86 +2,404 @@ package storageserver
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/chn0318/logstore/mapservice"
 	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/recovery"
 	"github.com/chn0318/logstore/sharedlog"
 )
 
+type Options struct {
+	// GroupCommitMaxBatch is the most records coalesced into one batched
+	// append. Group commit is disabled if it is <= 1.
+	GroupCommitMaxBatch int
+	// GroupCommitMaxDelay is how long a batch waits for more records.
+	GroupCommitMaxDelay time.Duration
+	// RequestParallelism is the most log appends or reads one request keeps
+	// in flight. Values <= 1 issue them one at a time.
+	RequestParallelism int
+	// MaxReadWait bounds how long MultiGet waits for min_commit_gsn.
+	MaxReadWait time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		GroupCommitMaxBatch: 256,
+		GroupCommitMaxDelay: 200 * time.Microsecond,
+		RequestParallelism:  16,
+		MaxReadWait:         5 * time.Second,
+	}
+}
+
 type StorageServer struct {
 	storagepb.UnimplementedStorageServer
 	sharedLog  sharedlog.SharedLog
 	mapService *mapservice.MapService
+	opts       Options
+
+	// keyLocks 保证 “校验 read set -> AppendCommit -> ApplyCommit” 对同一批 key 是原子的
+	keyLocks keyLocks
+	// clientLocks 让同一个 client 的重试排在原请求之后，再查去重表
+	clientLocks keyLocks
+	// groupCommit 为 nil 时每条记录单独 append
+	groupCommit *groupCommitter
 }
 
-func NewStorageServer(sharedLog sharedlog.SharedLog, mapService *mapservice.MapService) *StorageServer {
-	return &StorageServer{
+func NewStorageServer(sharedLog sharedlog.SharedLog, mapService *mapservice.MapService, opts Options) *StorageServer {
+	s := &StorageServer{
 		sharedLog:  sharedLog,
 		mapService: mapService,
+		opts:       opts,
 	}
+	if opts.GroupCommitMaxBatch > 1 {
+		s.groupCommit = newGroupCommitter(sharedLog, opts.GroupCommitMaxDelay, opts.GroupCommitMaxBatch)
+	}
+	return s
 }
 
+// MultiPut atomically writes req.Kvs. A request with a client ID is
+// deduplicated against the last request committed for that client.
 func (s *StorageServer) MultiPut(ctx context.Context, req *storagepb.MultiPutRequest) (*storagepb.MultiPutResponse, error) {
-	commitEntries := make([]sharedlog.CommitEntry, 0, len(req.Kvs))
+	if req.ClientId != "" {
+		// seq 0 是没设置的默认值，没法和上一个请求区分开
+		if req.Seq == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "client %q: seq must be set, starting at 1", req.ClientId)
+		}
+		unlock := s.clientLocks.lock([]string{req.ClientId})
+		defer unlock()
+		// 之前结果未知的 commit 可能已经写进了日志：先把它追进 map，否则重试会再写一遍
+		if s.mapService.NeedsReplay() {
+			if _, err := recovery.CatchUp(ctx, s.sharedLog, s.mapService); err != nil {
+				return nil, rpcError(err)
+			}
+		}
+		if last, ok := s.mapService.LastRequest(req.ClientId); ok {
+			switch {
+			case req.Seq == last.Seq:
+				return s.committedPut(ctx, last.CommitGSN)
+			case req.Seq < last.Seq:
+				return nil, status.Errorf(codes.FailedPrecondition,
+					"client %q: seq %d is older than the last committed seq %d", req.ClientId, req.Seq, last.Seq)
+			}
+		}
+	}
 
-	for _, kv := range req.Kvs {
-		dataRecord := sharedlog.DataRecord{
-			Key:   kv.Key,
-			Value: kv.Value,
+	// 在追加 DATA 之前就登记 pending commit，这样 GC 不会裁掉还没 commit 的 DATA
+	pending := s.mapService.BeginCommit()
+	commitEntries, err := s.appendData(ctx, req.Kvs)
+	if err != nil {
+		pending.Abort()
+		return nil, rpcError(err)
+	}
+
+	unlock := s.keyLocks.lock(entryKeys(commitEntries))
+	defer unlock()
+	commitGSN, err := s.commit(ctx, pending, sharedlog.CommitRecord{
+		Entries:  commitEntries,
+		ClientID: req.ClientId,
+		Seq:      req.Seq,
+	})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	return &storagepb.MultiPutResponse{
+		Ok:        true,
+		CommitGsn: commitGSN,
+		Refs:      putRefs(commitEntries),
+	}, nil
+}
+
+// committedPut rebuilds the response of a MultiPut that committed at
+// commitGSN from its commit record. Refs are left out if the record has been
+// trimmed.
+func (s *StorageServer) committedPut(ctx context.Context, commitGSN uint64) (*storagepb.MultiPutResponse, error) {
+	res := &storagepb.MultiPutResponse{Ok: true, CommitGsn: commitGSN}
+	err := s.sharedLog.ReplayCommits(ctx, commitGSN, commitGSN, func(_ uint64, rec sharedlog.CommitRecord) error {
+		res.Refs = putRefs(rec.Entries)
+		return nil
+	})
+	if err != nil && !errors.Is(err, sharedlog.ErrTrimmed) {
+		return nil, rpcError(err)
+	}
+	return res, nil
+}
+
+// putRefs returns the DATA refs of the non-tombstone entries by key.
+func putRefs(entries []sharedlog.CommitEntry) map[string]*storagepb.RecordRef {
+	refs := make(map[string]*storagepb.RecordRef, len(entries))
+	for _, e := range entries {
+		if e.Tombstone {
+			delete(refs, e.Key)
+			continue
 		}
+		refs[e.Key] = &storagepb.RecordRef{Gsn: e.Ref.GSN, ShardId: e.Ref.ShardID}
+	}
+	return refs
+}
 
-		ref, err := s.sharedLog.AppendData(dataRecord)
-		if err != nil {
-			return nil, err
+// MultiDelete atomically deletes keys by committing tombstones.
+func (s *StorageServer) MultiDelete(ctx context.Context, req *storagepb.MultiDeleteRequest) (*storagepb.MultiDeleteResponse, error) {
+	kvs := make([]*storagepb.KV, 0, len(req.Keys))
+	for _, k := range req.Keys {
+		kvs = append(kvs, &storagepb.KV{Key: k, Delete: true})
+	}
+	if _, err := s.MultiPut(ctx, &storagepb.MultiPutRequest{Kvs: kvs}); err != nil {
+		return nil, err
+	}
+	return &storagepb.MultiDeleteResponse{Ok: true}, nil
+}
+
+// Txn is MultiPut with optimistic concurrency control: the data records are
+// appended first, then under the key locks every read is validated against
+// the key's current CommitGSN and the commit record is appended only if none
+// of them changed. On conflict the data records stay in the log unreferenced.
+func (s *StorageServer) Txn(ctx context.Context, req *storagepb.TxnRequest) (*storagepb.TxnResponse, error) {
+	pending := s.mapService.BeginCommit()
+	commitEntries, err := s.appendData(ctx, req.Writes)
+	if err != nil {
+		pending.Abort()
+		return nil, rpcError(err)
+	}
+
+	keys := entryKeys(commitEntries)
+	for _, r := range req.Reads {
+		keys = append(keys, r.Key)
+	}
+	unlock := s.keyLocks.lock(keys)
+	defer unlock()
+
+	if conflicts := s.validate(req.Reads); len(conflicts) > 0 {
+		pending.Abort()
+		return nil, status.Errorf(codes.Aborted, "txn conflict on keys: %s", strings.Join(conflicts, ", "))
+	}
+	if len(commitEntries) == 0 {
+		pending.Abort()
+		return &storagepb.TxnResponse{Ok: true}, nil
+	}
+
+	commitGSN, err := s.commit(ctx, pending, sharedlog.CommitRecord{Entries: commitEntries})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return &storagepb.TxnResponse{
+		Ok:        true,
+		CommitGsn: commitGSN,
+	}, nil
+}
+
+// validate returns the keys whose CommitGSN no longer matches what the
+// client observed. Caller holds the key locks.
+func (s *StorageServer) validate(reads []*storagepb.ReadItem) []string {
+	keys := make([]string, 0, len(reads))
+	for _, r := range reads {
+		keys = append(keys, r.Key)
+	}
+	metas := s.mapService.GetMetas(keys)
+
+	var conflicts []string
+	for _, r := range reads {
+		meta, ok := metas[r.Key]
+		switch {
+		case !ok:
+			if r.CommitGsn != 0 {
+				conflicts = append(conflicts, r.Key)
+			}
+		case meta.Deleted:
+			// MultiGet reports deleted keys as absent, so 0 is a valid
+			// observation of a deleted key.
+			if r.CommitGsn != 0 && r.CommitGsn != meta.CommitGSN {
+				conflicts = append(conflicts, r.Key)
+			}
+		case meta.CommitGSN != r.CommitGsn:
+			conflicts = append(conflicts, r.Key)
 		}
+	}
+	return conflicts
+}
 
+// appendData appends one DATA record per kv and returns the commit entries
+// pointing at them. Deletes append nothing and become tombstone entries.
+func (s *StorageServer) appendData(ctx context.Context, kvs []*storagepb.KV) ([]sharedlog.CommitEntry, error) {
+	commitEntries := make([]sharedlog.CommitEntry, 0, len(kvs))
+	dataRecords := make([]sharedlog.DataRecord, 0, len(kvs))
+
+	for _, kv := range kvs {
+		if kv.Delete {
+			commitEntries = append(commitEntries, sharedlog.CommitEntry{
+				Key:       kv.Key,
+				Tombstone: true,
+			})
+			continue
+		}
+
+		dataRecords = append(dataRecords, sharedlog.DataRecord{
+			Key:   kv.Key,
+			Value: kv.Value,
+		})
 		commitEntries = append(commitEntries, sharedlog.CommitEntry{
 			Key: kv.Key,
-			Ref: ref,
 		})
 	}
 
-	commitGSN, err := s.sharedLog.AppendCommit(sharedlog.CommitRecord{
-		Entries: commitEntries,
+	refs, err := s.appendDataRecords(ctx, dataRecords)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	for j := range commitEntries {
+		if commitEntries[j].Tombstone {
+			continue
+		}
+		commitEntries[j].Ref = refs[i]
+		i++
+	}
+	return commitEntries, nil
+}
+
+func (s *StorageServer) appendDataRecords(ctx context.Context, recs []sharedlog.DataRecord) ([]sharedlog.RecordRef, error) {
+	if s.groupCommit != nil {
+		return s.groupCommit.data.submit(ctx, recs)
+	}
+	refs := make([]sharedlog.RecordRef, len(recs))
+	err := forEach(ctx, len(recs), s.opts.RequestParallelism, func(ctx context.Context, i int) error {
+		ref, err := s.sharedLog.AppendData(ctx, recs[i])
+		refs[i] = ref
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
+	return refs, nil
+}
 
-	msEntries := make([]mapservice.CommitEntry, 0, len(commitEntries))
-	for _, e := range commitEntries {
-		msEntries = append(msEntries, mapservice.CommitEntry{
-			Key: e.Key,
-			Ref: e.Ref,
-		})
+func (s *StorageServer) appendCommit(ctx context.Context, rec sharedlog.CommitRecord) (uint64, error) {
+	if s.groupCommit != nil {
+		gsns, err := s.groupCommit.commit.submit(ctx, []sharedlog.CommitRecord{rec})
+		if err != nil {
+			return 0, err
+		}
+		return gsns[0], nil
 	}
-	s.mapService.ApplyCommit(commitGSN, msEntries)
+	return s.sharedLog.AppendCommit(ctx, rec)
+}
 
-	return &storagepb.MultiPutResponse{
-		Ok: true,
-	}, nil
+// commit appends rec and applies it to the map service, finishing pending
+// either way. Caller holds the key locks of rec.Entries, and the client lock
+// if rec has a client ID.
+//
+// The append ignores cancellation of ctx: an abandoned COMMIT could still
+// land in the log without being applied, and would then only show up after
+// a restart.
+func (s *StorageServer) commit(ctx context.Context, pending *mapservice.PendingCommit, rec sharedlog.CommitRecord) (uint64, error) {
+	commitGSN, err := s.appendCommit(context.WithoutCancel(ctx), rec)
+	if err != nil {
+		// 报错的 append 也可能已经写进日志（例如 leader 切换），交给 CatchUp 去补
+		pending.AbortUnknown()
+		return 0, err
+	}
+
+	// 在 commit 变得可见（StableGSN 越过它）之前登记，checkpoint 才不会漏掉
+	if rec.ClientID != "" {
+		s.mapService.RecordRequest(rec.ClientID, rec.Seq, commitGSN)
+	}
+	pending.Apply(commitGSN, mapservice.EntriesFromLog(rec.Entries))
+	return commitGSN, nil
+}
+
+// rpcError maps log errors that have a natural gRPC status: a client
+// deadline surfaces as DeadlineExceeded rather than Unknown, and a replica
+// that is not the leader as Unavailable so clients retry elsewhere.
+func rpcError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, sharedlog.ErrNotLeader):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+func entryKeys(entries []sharedlog.CommitEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
 }
 
 func (s *StorageServer) MultiGet(ctx context.Context, req *storagepb.MultiGetRequest) (*storagepb.MultiGetResponse, error) {
-	offsets := s.mapService.GetOffsets(req.Keys)
+	// read-your-writes：写可能发生在别的 storage server 上，先从日志追上再读
+	if req.MinCommitGsn > 0 {
+		if err := s.waitApplied(ctx, req.MinCommitGsn); err != nil {
+			return nil, rpcError(err)
+		}
+	}
+
+	var metas map[string]mapservice.KeyMeta
+	if req.SnapshotGsn == 0 {
+		metas = s.mapService.GetMetas(req.Keys)
+	} else {
+		// 快照读：先等所有 GSN <= snapshot_gsn 的 commit 都已经 apply
+		if req.SnapshotGsn > s.mapService.MaxCommitGSN() {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"snapshot_gsn %d is ahead of applied commits", req.SnapshotGsn)
+		}
+		if h := s.mapService.SnapshotHorizon(); req.SnapshotGsn < h {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"snapshot_gsn %d is older than the gc horizon %d", req.SnapshotGsn, h)
+		}
+		if err := s.mapService.WaitStable(ctx, req.SnapshotGsn); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		metas = s.mapService.GetMetasAt(req.Keys, req.SnapshotGsn)
+	}
 
 	res := &storagepb.MultiGetResponse{
-		Values: make(map[string][]byte, len(offsets)),
+		Values:     make(map[string][]byte, len(metas)),
+		CommitGsns: make(map[string]uint64, len(metas)),
 	}
 
+	keys := make([]string, 0, len(metas))
+	refs := make([]sharedlog.RecordRef, 0, len(metas))
 	for _, key := range req.Keys {
-		ref, ok := offsets[key]
-		if !ok {
+		meta, ok := metas[key]
+		if !ok || meta.Deleted {
 			continue
 		}
+		keys = append(keys, key)
+		refs = append(refs, meta.Ref)
+		res.CommitGsns[key] = meta.CommitGSN
+	}
 
-		dataRec, err := s.sharedLog.ReadData(ref)
-		if err != nil {
-			return nil, err
-		}
-
-		res.Values[key] = dataRec.Value
+	values, err := s.readValues(ctx, refs)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	for i, key := range keys {
+		res.Values[key] = values[i]
 	}
 
 	return res, nil
 }
+
+// readValues reads the DATA records behind refs concurrently, up to
+// RequestParallelism at a time, and returns their values in order.
+func (s *StorageServer) readValues(ctx context.Context, refs []sharedlog.RecordRef) ([][]byte, error) {
+	values := make([][]byte, len(refs))
+	err := forEach(ctx, len(refs), s.opts.RequestParallelism, func(ctx context.Context, i int) error {
+		rec, err := s.sharedLog.ReadData(ctx, refs[i])
+		values[i] = rec.Value
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
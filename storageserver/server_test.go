@@ -0,0 +1,191 @@
+package storageserver
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/sharedlog/memorylog"
+)
+
+func newServer() *StorageServer {
+	return NewStorageServer(memorylog.NewMemoryLog(), mapservice.NewMapService(), DefaultOptions())
+}
+
+// write puts key=value and returns the commit GSN MultiGet reports for it.
+func write(t *testing.T, s *StorageServer, key, value string) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.MultiPut(ctx, &storagepb.MultiPutRequest{Kvs: []*storagepb.KV{{Key: key, Value: []byte(value)}}}); err != nil {
+		t.Fatal(err)
+	}
+	return read(t, s, key).CommitGsns[key]
+}
+
+func read(t *testing.T, s *StorageServer, keys ...string) *storagepb.MultiGetResponse {
+	t.Helper()
+	res, err := s.MultiGet(context.Background(), &storagepb.MultiGetRequest{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestTxn(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// reads of "a", which holds a committed value, and of the never
+		// written "b"; aGSN resolves to the commit GSN of "a"
+		reads    func(aGSN uint64) []*storagepb.ReadItem
+		conflict bool
+	}{
+		{"current read", func(g uint64) []*storagepb.ReadItem { return []*storagepb.ReadItem{{Key: "a", CommitGsn: g}} }, false},
+		{"stale read", func(g uint64) []*storagepb.ReadItem { return []*storagepb.ReadItem{{Key: "a", CommitGsn: g - 1}} }, true},
+		{"read as absent", func(g uint64) []*storagepb.ReadItem { return []*storagepb.ReadItem{{Key: "a"}} }, true},
+		{"absent key", func(g uint64) []*storagepb.ReadItem { return []*storagepb.ReadItem{{Key: "b"}} }, false},
+		{"absent key read as present", func(g uint64) []*storagepb.ReadItem { return []*storagepb.ReadItem{{Key: "b", CommitGsn: g}} }, true},
+		{"one of several stale", func(g uint64) []*storagepb.ReadItem {
+			return []*storagepb.ReadItem{{Key: "a", CommitGsn: g}, {Key: "b", CommitGsn: 1}}
+		}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newServer()
+			aGSN := write(t, s, "a", "1")
+			res, err := s.Txn(ctx, &storagepb.TxnRequest{
+				Reads:  tc.reads(aGSN),
+				Writes: []*storagepb.KV{{Key: "a", Value: []byte("2")}},
+			})
+			got := read(t, s, "a")
+			if tc.conflict {
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("err = %v, want Aborted", err)
+				}
+				if string(got.Values["a"]) != "1" || got.CommitGsns["a"] != aGSN {
+					t.Fatalf("a = %q at %d after a conflict, want the old value", got.Values["a"], got.CommitGsns["a"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.Values["a"]) != "2" || got.CommitGsns["a"] != res.CommitGsn {
+				t.Fatalf("a = %q at %d, want the txn's write at %d", got.Values["a"], got.CommitGsns["a"], res.CommitGsn)
+			}
+		})
+	}
+}
+
+func TestTxnReadOnly(t *testing.T) {
+	ctx := context.Background()
+	s := newServer()
+	g := write(t, s, "a", "1")
+	res, err := s.Txn(ctx, &storagepb.TxnRequest{Reads: []*storagepb.ReadItem{{Key: "a", CommitGsn: g}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CommitGsn != 0 {
+		t.Fatalf("read-only txn committed at %d", res.CommitGsn)
+	}
+	if got := read(t, s, "a").CommitGsns["a"]; got != g {
+		t.Fatalf("a committed at %d after a read-only txn, want %d", got, g)
+	}
+}
+
+func TestMultiDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newServer()
+	old := write(t, s, "a", "1")
+	write(t, s, "b", "1")
+	if _, err := s.MultiDelete(ctx, &storagepb.MultiDeleteRequest{Keys: []string{"a", "missing"}}); err != nil {
+		t.Fatal(err)
+	}
+	got := read(t, s, "a", "b", "missing")
+	if _, ok := got.Values["a"]; ok || len(got.Values) != 1 {
+		t.Fatalf("MultiGet after delete = %v, want only b", got.Values)
+	}
+	if _, ok := got.CommitGsns["a"]; ok {
+		t.Fatalf("deleted key reported with commit GSN %d", got.CommitGsns["a"])
+	}
+	tomb := s.mapService.GetMetas([]string{"a"})["a"]
+	if !tomb.Deleted || tomb.CommitGSN <= old {
+		t.Fatalf("meta of the deleted key = %+v", tomb)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		gsn      uint64
+		conflict bool
+	}{
+		// MultiGet reports a deleted key as absent
+		{"read as absent", 0, false},
+		{"read the tombstone", tomb.CommitGSN, false},
+		{"read before the delete", old, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Txn(ctx, &storagepb.TxnRequest{Reads: []*storagepb.ReadItem{{Key: "a", CommitGsn: tc.gsn}}})
+			if conflict := status.Code(err) == codes.Aborted; conflict != tc.conflict || (err != nil && !conflict) {
+				t.Fatalf("err = %v, want conflict %v", err, tc.conflict)
+			}
+		})
+	}
+
+	// a delete and a write of the same key in one commit: the last one wins
+	if _, err := s.MultiPut(ctx, &storagepb.MultiPutRequest{Kvs: []*storagepb.KV{
+		{Key: "b", Delete: true},
+		{Key: "a", Value: []byte("2")},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	got = read(t, s, "a", "b")
+	if string(got.Values["a"]) != "2" || len(got.Values) != 1 {
+		t.Fatalf("MultiGet after rewrite = %v, want a=2 and b deleted", got.Values)
+	}
+}
+
+func TestSnapshotRead(t *testing.T) {
+	ctx := context.Background()
+	s := newServer()
+	g1 := write(t, s, "a", "1")
+	g2 := write(t, s, "a", "2")
+	write(t, s, "b", "1")
+	if _, err := s.MultiDelete(ctx, &storagepb.MultiDeleteRequest{Keys: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	last := s.mapService.MaxCommitGSN()
+
+	for _, tc := range []struct {
+		name string
+		at   uint64
+		want map[string]string
+	}{
+		{"first write", g1, map[string]string{"a": "1"}},
+		{"between writes", g2 - 1, map[string]string{"a": "1"}},
+		{"second write", g2, map[string]string{"a": "2"}},
+		{"after the delete", last, map[string]string{"b": "1"}},
+		{"latest", 0, map[string]string{"b": "1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := s.MultiGet(ctx, &storagepb.MultiGetRequest{Keys: []string{"a", "b"}, SnapshotGsn: tc.at})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Values) != len(tc.want) {
+				t.Fatalf("values at %d = %v, want %v", tc.at, res.Values, tc.want)
+			}
+			for k, v := range tc.want {
+				if string(res.Values[k]) != v {
+					t.Fatalf("values at %d = %v, want %v", tc.at, res.Values, tc.want)
+				}
+			}
+		})
+	}
+
+	if _, err := s.MultiGet(ctx, &storagepb.MultiGetRequest{Keys: []string{"a"}, SnapshotGsn: last + 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("snapshot ahead of the applied commits: err = %v, want FailedPrecondition", err)
+	}
+}
@@ -0,0 +1,127 @@
+package storageserver
+
+import (
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/mapservice"
+	"github.com/chn0318/logstore/proto/storagepb"
+	"github.com/chn0318/logstore/sharedlog"
+)
+
+// watchBuffer is how many commits a Watch stream may fall behind the map
+// service before it is dropped.
+const watchBuffer = 1024
+
+// Watch streams the committed changes to the selected keys in commit GSN
+// order. Commits already applied when the stream starts are read back from
+// the log; later ones come from the map service as they become stable.
+func (s *StorageServer) Watch(req *storagepb.WatchRequest, stream grpc.ServerStreamingServer[storagepb.WatchResponse]) error {
+	ctx := stream.Context()
+	match := watchFilter(req)
+
+	// 先订阅，再补历史，两段在 w.From() 处衔接，不会漏掉 commit
+	w := s.mapService.Watch(watchBuffer)
+	defer w.Close()
+
+	last := w.From()
+	if req.StartGsn != 0 {
+		last = req.StartGsn
+	}
+	if req.StartGsn != 0 && req.StartGsn < w.From() {
+		head := s.sharedLog.Head(ctx)
+		if req.StartGsn+1 < head {
+			return status.Errorf(codes.OutOfRange, "start_gsn %d was trimmed from the log (head %d)", req.StartGsn, head)
+		}
+		err := s.sharedLog.ReplayCommits(ctx, req.StartGsn+1, w.From(), func(gsn uint64, rec sharedlog.CommitRecord) error {
+			entries := mapservice.EntriesFromLog(rec.Entries)
+			for _, e := range entries {
+				if !e.Tombstone && match(e.Key) && e.Ref.GSN < head {
+					return status.Errorf(codes.OutOfRange, "start_gsn %d was trimmed from the log (head %d)", req.StartGsn, head)
+				}
+			}
+			if err := s.sendChange(stream, match, gsn, entries); err != nil {
+				return err
+			}
+			last = gsn
+			return nil
+		})
+		if err != nil {
+			return rpcError(err)
+		}
+	}
+
+	for {
+		select {
+		case ch, ok := <-w.C():
+			if !ok {
+				return status.Errorf(codes.ResourceExhausted, "watch fell behind; resume with start_gsn %d", last)
+			}
+			if ch.CommitGSN <= last {
+				continue
+			}
+			if err := s.sendChange(stream, match, ch.CommitGSN, ch.Entries); err != nil {
+				return rpcError(err)
+			}
+			last = ch.CommitGSN
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// sendChange sends the entries of one commit that match, if any.
+func (s *StorageServer) sendChange(stream grpc.ServerStreamingServer[storagepb.WatchResponse], match func(string) bool, commitGSN uint64, entries []mapservice.CommitEntry) error {
+	var hits []mapservice.CommitEntry
+	var refs []sharedlog.RecordRef
+	for _, e := range entries {
+		if !match(e.Key) {
+			continue
+		}
+		hits = append(hits, e)
+		if !e.Tombstone {
+			refs = append(refs, e.Ref)
+		}
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+	values, err := s.readValues(stream.Context(), refs)
+	if err != nil {
+		return err
+	}
+	resp := &storagepb.WatchResponse{
+		CommitGsn: commitGSN,
+		Kvs:       make([]*storagepb.KV, 0, len(hits)),
+	}
+	for _, e := range hits {
+		if e.Tombstone {
+			resp.Kvs = append(resp.Kvs, &storagepb.KV{Key: e.Key, Delete: true})
+			continue
+		}
+		resp.Kvs = append(resp.Kvs, &storagepb.KV{Key: e.Key, Value: values[0]})
+		values = values[1:]
+	}
+	return stream.Send(resp)
+}
+
+func watchFilter(req *storagepb.WatchRequest) func(string) bool {
+	switch {
+	case req.Prefix != "":
+		return func(key string) bool { return strings.HasPrefix(key, req.Prefix) }
+	case len(req.Keys) > 0:
+		keys := make(map[string]struct{}, len(req.Keys))
+		for _, k := range req.Keys {
+			keys[k] = struct{}{}
+		}
+		return func(key string) bool {
+			_, ok := keys[key]
+			return ok
+		}
+	default:
+		return func(string) bool { return true }
+	}
+}
@@ -0,0 +1,120 @@
+package storageserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/chn0318/logstore/proto/storagepb"
+)
+
+// fakeStream is the server side of a streaming RPC whose messages go to c.
+type fakeStream[T any] struct {
+	grpc.ServerStream
+	ctx context.Context
+	c   chan *T
+}
+
+func (s *fakeStream[T]) Context() context.Context { return s.ctx }
+
+func (s *fakeStream[T]) Send(m *T) error {
+	select {
+	case s.c <- m:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// stream runs rpc with a fake stream until the test ends. errC gets the
+// RPC's error.
+func stream[T any](t *testing.T, rpc func(grpc.ServerStreamingServer[T]) error) (c <-chan *T, errC <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &fakeStream[T]{ctx: ctx, c: make(chan *T, 100)}
+	ec := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		ec <- rpc(s)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return s.c, ec
+}
+
+func next[T any](t *testing.T, c <-chan *T) *T {
+	t.Helper()
+	select {
+	case m := <-c:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+func watch(t *testing.T, s *StorageServer, req *storagepb.WatchRequest) (<-chan *storagepb.WatchResponse, <-chan error) {
+	t.Helper()
+	return stream(t, func(st grpc.ServerStreamingServer[storagepb.WatchResponse]) error { return s.Watch(req, st) })
+}
+
+func TestWatchResume(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// start picks the start GSN among the commits of a=1, b=1 and a=2
+		start  func(g []uint64) uint64
+		keys   []string
+		prefix string
+		// want is the indexes of the commits delivered before the live write
+		want []int
+	}{
+		{"resume after the first commit", func(g []uint64) uint64 { return g[0] }, nil, "", []int{1, 2}},
+		{"resume at the last commit", func(g []uint64) uint64 { return g[2] }, nil, "", nil},
+		{"resume with a key filter", func(g []uint64) uint64 { return g[0] }, []string{"b", "ab"}, "", []int{1}},
+		{"resume with a prefix", func(g []uint64) uint64 { return g[0] }, nil, "a", []int{2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer()
+			g := []uint64{write(t, s, "a", "1"), write(t, s, "b", "1"), write(t, s, "a", "2")}
+			c, _ := watch(t, s, &storagepb.WatchRequest{StartGsn: tc.start(g), Keys: tc.keys, Prefix: tc.prefix})
+			for _, i := range tc.want {
+				gsn := g[i]
+				if got := next(t, c); got.CommitGsn != gsn {
+					t.Fatalf("commit %d delivered, want %d", got.CommitGsn, gsn)
+				}
+			}
+			// a live write to a key every filter matches
+			live := write(t, s, "ab", "live")
+			got := next(t, c)
+			if got.CommitGsn != live || len(got.Kvs) != 1 || string(got.Kvs[0].Value) != "live" {
+				t.Fatalf("live change = %v, want ab=live at %d", got, live)
+			}
+		})
+	}
+}
+
+func TestWatchTrimmedStart(t *testing.T) {
+	ctx := context.Background()
+	s := newServer()
+	g1 := write(t, s, "a", "1")
+	write(t, s, "a", "2")
+	if err := s.sharedLog.Trim(ctx, g1+1); err != nil {
+		t.Fatal(err)
+	}
+	_, errC := watch(t, s, &storagepb.WatchRequest{StartGsn: g1 - 1})
+	select {
+	case err := <-errC:
+		if status.Code(err) != codes.OutOfRange {
+			t.Fatalf("err = %v, want OutOfRange", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch from a trimmed GSN did not fail")
+	}
+}